@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sniper/internal/backtest"
+	"sniper/internal/config"
+	"sniper/internal/discovery"
+	"sniper/internal/doctor"
+	"sniper/internal/eventlog"
+	"sniper/internal/parser"
+	"sniper/internal/secrets"
+	"sniper/internal/tape"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/goccy/go-json"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+var configFlag = &cli.StringFlag{
+	Name:    "config",
+	Value:   "config.yaml",
+	EnvVars: []string{"SNIPER_CONFIG"},
+	Usage:   "Path to the config file",
+}
+
+// commandConfigFlag lets --config also follow a subcommand. It has no default of its own, so
+// `sniper --config prod.yaml doctor` keeps the path given before the command.
+var commandConfigFlag = &cli.StringFlag{
+	Name:  "config",
+	Usage: "Path to the config file (default: the --config given before the command)",
+}
+
+// configPath is the --config closest to the command that was run, the root flag holds the default.
+func configPath(ctx *cli.Context) string {
+	for _, c := range ctx.Lineage() {
+		if path := c.String("config"); path != "" {
+			return path
+		}
+	}
+	return configFlag.Value
+}
+
+var doctorCommand = &cli.Command{
+	Name:  "doctor",
+	Usage: "Check the config, limiteds file, cookie, CSRF token and webhooks before sniping.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "Path to the main file with Limited information, format is <id>,<price>[,key=value...]",
+		},
+		commandConfigFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		failures := doctor.Run(configPath(ctx), ctx.String("file"))
+		if failures > 0 {
+			return cli.Exit(fmt.Sprintf("doctor found %d problem(s)", failures), 1)
+		}
+
+		return nil
+	},
+}
+
+var secretsCommand = &cli.Command{
+	Name:  "secrets",
+	Usage: "Manage the encrypted secrets file.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "encrypt",
+			Usage: "Encrypt a plain secrets file (cookie, webhook_url) with a passphrase.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "in",
+					Value: secrets.DefaultFile,
+					Usage: "Plain YAML secrets file to encrypt",
+				},
+				&cli.StringFlag{
+					Name:  "out",
+					Value: secrets.DefaultEncryptedFile,
+					Usage: "Where to write the encrypted file",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				data, err := os.ReadFile(ctx.String("in"))
+				if err != nil {
+					return err
+				}
+
+				var plain secrets.Secrets
+				if err := yaml.Unmarshal(data, &plain); err != nil {
+					return fmt.Errorf("failed to decode %s: %w", ctx.String("in"), err)
+				}
+
+				passphrase, err := secrets.Passphrase()
+				if err != nil {
+					return err
+				}
+
+				encrypted, err := secrets.Encrypt(plain, passphrase)
+				if err != nil {
+					return err
+				}
+
+				if err := os.WriteFile(ctx.String("out"), encrypted, 0o600); err != nil {
+					return err
+				}
+
+				log.Info("🔒 Secrets Encrypted", "File", ctx.String("out"))
+				log.Warn("Delete the plain file once you checked the encrypted one works.", "File", ctx.String("in"))
+				return nil
+			},
+		},
+	},
+}
+
+var configCommand = &cli.Command{
+	Name:  "config",
+	Usage: "Inspect the configuration.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "print",
+			Usage: "Print the effective config (file, environment and defaults merged) with secrets redacted.",
+			Flags: []cli.Flag{
+				commandConfigFlag,
+			},
+			Action: func(ctx *cli.Context) error {
+				cfg, err := config.LoadConfig(configPath(ctx))
+				if err != nil {
+					return err
+				}
+
+				if err := secrets.Apply(cfg); err != nil {
+					return err
+				}
+
+				out, err := yaml.Marshal(config.Redact(cfg))
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(out))
+
+				if err := config.Validate(cfg); err != nil {
+					log.Warn(err)
+				}
+				return nil
+			},
+		},
+	},
+}
+
+var tapeCommand = &cli.Command{
+	Name:  "tape",
+	Usage: "Work with the recorded price tape.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "export",
+			Usage: "Export observed listings as CSV or JSON.",
+			Flags: []cli.Flag{
+				commandConfigFlag,
+				&cli.StringSliceFlag{
+					Name:  "item",
+					Usage: "Limited ID to export, repeat for more, every item when omitted",
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Value: tape.FormatCSV,
+					Usage: "Output format, csv or json",
+				},
+				&cli.StringFlag{
+					Name:  "since",
+					Usage: "Only export listings newer than a duration (e.g. 24h) or an RFC 3339 time",
+				},
+				&cli.StringFlag{
+					Name:  "dir",
+					Usage: "Tape directory, defaults to tape.directory from the config",
+				},
+				&cli.StringFlag{
+					Name:  "out",
+					Usage: "File to write, stdout when omitted",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				dir := ctx.String("dir")
+				if dir == "" {
+					cfg, err := config.LoadConfig(configPath(ctx))
+					if err != nil {
+						return err
+					}
+					dir = cfg.Tape.Directory
+				}
+				if dir == "" {
+					return cli.Exit("the tape is disabled, set tape.directory or pass --dir", 1)
+				}
+
+				since, err := parseSince(ctx.String("since"))
+				if err != nil {
+					return err
+				}
+
+				out := os.Stdout
+				if path := ctx.String("out"); path != "" {
+					f, err := os.Create(path)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					out = f
+				}
+
+				count, err := tape.Export(out, dir, ctx.StringSlice("item"), ctx.String("format"), since)
+				if err != nil {
+					return err
+				}
+
+				log.Info("📼 Tape Exported", "Records", count)
+				return nil
+			},
+		},
+	},
+}
+
+// parseSince accepts a duration back from now or an RFC 3339 time, empty means everything.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-ago), nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, use a duration (24h) or an RFC 3339 time", value)
+	}
+	return since, nil
+}
+
+var backtestCommand = &cli.Command{
+	Name:  "backtest",
+	Usage: "Replay the recorded price tape through the buy rules of a candidate watchlist.",
+	Flags: []cli.Flag{
+		commandConfigFlag,
+		&cli.StringFlag{
+			Name:     "file",
+			Required: true,
+			Usage:    "Candidate watchlist, same format as the main limiteds file",
+		},
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "Tape directory, defaults to tape.directory from the config",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "Simulated poll interval, defaults to rate_limit_time_ms from the config",
+		},
+		&cli.DurationFlag{
+			Name:  "latency",
+			Value: 200 * time.Millisecond,
+			Usage: "Simulated time from seeing a listing to the purchase landing",
+		},
+		&cli.IntFlag{
+			Name:  "budget",
+			Usage: "Robux available for the whole run, 0 for unlimited",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Only replay listings newer than a duration (e.g. 24h) or an RFC 3339 time",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "Only replay listings older than an RFC 3339 time",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "Output format, text or json",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		cfg, err := config.LoadConfig(configPath(ctx))
+		if err != nil {
+			return err
+		}
+
+		limiteds, err := parser.FromFile(ctx.String("file"))
+		if err != nil {
+			return err
+		}
+
+		opts := backtest.Options{
+			Dir:      ctx.String("dir"),
+			Limiteds: limiteds,
+			Interval: ctx.Duration("interval"),
+			Latency:  ctx.Duration("latency"),
+			Budget:   ctx.Int("budget"),
+		}
+		if opts.Dir == "" {
+			opts.Dir = cfg.Tape.Directory
+		}
+		if opts.Interval == 0 {
+			opts.Interval = time.Duration(cfg.Rate) * time.Millisecond
+		}
+
+		if opts.Since, err = parseSince(ctx.String("since")); err != nil {
+			return err
+		}
+		if until := ctx.String("until"); until != "" {
+			if opts.Until, err = time.Parse(time.RFC3339, until); err != nil {
+				return fmt.Errorf("invalid --until %q, use an RFC 3339 time", until)
+			}
+		}
+
+		result, err := backtest.Run(opts)
+		if err != nil {
+			return err
+		}
+
+		switch ctx.String("format") {
+		case "json":
+			out, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		case "text":
+			fmt.Print(result.Text())
+		default:
+			return fmt.Errorf("unknown format %q, use text or json", ctx.String("format"))
+		}
+
+		return nil
+	},
+}
+
+var discoveriesCommand = &cli.Command{
+	Name:  "discoveries",
+	Usage: "List the deals catalog discovery found and whether they were bought.",
+	Flags: []cli.Flag{
+		commandConfigFlag,
+		&cli.StringFlag{
+			Name:  "since",
+			Value: "24h",
+			Usage: "Only list discoveries newer than a duration (e.g. 24h) or an RFC 3339 time",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "Output format, text or json",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		cfg, err := config.LoadConfig(configPath(ctx))
+		if err != nil {
+			return err
+		}
+
+		since, err := parseSince(ctx.String("since"))
+		if err != nil {
+			return err
+		}
+
+		logged, err := eventlog.ReadFile(cfg.EventLog, since)
+		if err != nil {
+			return err
+		}
+		reviewed := discovery.Review(logged)
+
+		switch ctx.String("format") {
+		case "json":
+			out, err := json.MarshalIndent(reviewed, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		case "text":
+			fmt.Print(discovery.Text(reviewed))
+		default:
+			return fmt.Errorf("unknown format %q, use text or json", ctx.String("format"))
+		}
+
+		return nil
+	},
+}
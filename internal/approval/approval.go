@@ -0,0 +1,179 @@
+package approval
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sniper/internal/config"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("approval")
+
+// Decisions on a request.
+const (
+	DecisionApproved = "approved"
+	DecisionDenied   = "denied"
+	DecisionExpired  = "expired"
+	DecisionStale    = "stale" // Approved, but the listing was gone by then
+)
+
+// Request is a listing held until its purchase is approved or denied.
+type Request struct {
+	ID        string         `json:"id"`
+	LimitedID string         `json:"limited_id"`
+	ItemName  string         `json:"item_name,omitempty"`
+	Listing   events.Listing `json:"listing"`
+	Target    int            `json:"target"`
+	Reason    string         `json:"reason"` // Why the purchase needs an approval
+	Created   time.Time      `json:"created"`
+	Expires   time.Time      `json:"expires"`
+
+	decided chan string
+}
+
+var (
+	timeout  = 5 * time.Minute
+	minPrice int
+	pending  = map[string]*Request{} // Request ID -> request
+	mu       sync.Mutex
+)
+
+// Init sets how long a request waits for a decision, which buy entries need one and how
+// approval links are signed.
+func Init(cfg config.ApprovalConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	timeout = time.Duration(cfg.Timeout) * time.Second
+	minPrice = cfg.MinPrice
+	linkBase = strings.TrimRight(cfg.LinkBaseURL, "/")
+	linkSecret = []byte(cfg.LinkSecret)
+}
+
+// Required reports whether buying a listing at this price needs an approval.
+func Required(price int) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return minPrice > 0 && price >= minPrice
+}
+
+// OpenIfAbsent holds a listing for approval unless a listing of the item is already waiting for
+// a decision, the caller waits for the decision with Wait. The check and the insert happen under
+// one lock, so two hits on the same item never both open a request.
+func OpenIfAbsent(limitedID, itemName string, listing events.Listing, target int, reason string) (*Request, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, r := range pending {
+		if r.LimitedID == limitedID {
+			return nil, false
+		}
+	}
+
+	now := time.Now()
+	r := &Request{
+		ID:        newID(),
+		LimitedID: limitedID,
+		ItemName:  itemName,
+		Listing:   listing,
+		Target:    target,
+		Reason:    reason,
+		Created:   now,
+		Expires:   now.Add(timeout),
+		decided:   make(chan string, 1),
+	}
+	pending[r.ID] = r
+
+	logger.Info("Purchase waiting for approval", "Approval ID", r.ID, "Limited ID", limitedID, "Price", listing.Price, "Reason", reason, "Expires", r.Expires.Format(time.Kitchen))
+	ask(r)
+	return r, true
+}
+
+// Wait blocks until the request is approved, denied or expires.
+func (r *Request) Wait() string {
+	select {
+	case decision := <-r.decided:
+		return decision
+	case <-time.After(time.Until(r.Expires)):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// A decision may have come in right as the request expired
+	select {
+	case decision := <-r.decided:
+		return decision
+	default:
+	}
+	delete(pending, r.ID)
+	return DecisionExpired
+}
+
+// Approve lets the purchase of a pending request go ahead.
+func Approve(id string) error {
+	return decide(id, DecisionApproved)
+}
+
+// Deny drops a pending request.
+func Deny(id string) error {
+	return decide(id, DecisionDenied)
+}
+
+func decide(id, decision string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r, ok := pending[id]
+	if !ok {
+		return fmt.Errorf("no pending approval %s, it may have expired", id)
+	}
+	delete(pending, id)
+	r.decided <- decision
+
+	logger.Info("Approval decided", "Approval ID", id, "Limited ID", r.LimitedID, "Decision", decision)
+	return nil
+}
+
+// Pending returns the requests waiting for a decision, oldest first.
+func Pending() []Request {
+	mu.Lock()
+	defer mu.Unlock()
+
+	requests := make([]Request, 0, len(pending))
+	for _, r := range pending {
+		requests = append(requests, *r)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Created.Before(requests[j].Created)
+	})
+	return requests
+}
+
+// PendingFor reports whether a listing of the item is waiting for a decision. It is only a hint,
+// use OpenIfAbsent to open a request.
+func PendingFor(limitedID string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, r := range pending {
+		if r.LimitedID == limitedID {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(buf)
+}
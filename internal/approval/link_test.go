@@ -0,0 +1,93 @@
+package approval
+
+import (
+	"net/url"
+	"sniper/internal/config"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseLink splits an approval link into the id, expiry and signature the control API checks.
+func parseLink(t *testing.T, link string) (string, string, string) {
+	t.Helper()
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, ok := strings.CutPrefix(u.Path, "/approval/")
+	if !ok {
+		t.Fatalf("unexpected link path %q", u.Path)
+	}
+	return id, u.Query().Get("expires"), u.Query().Get("sig")
+}
+
+func TestVerify(t *testing.T) {
+	Init(config.ApprovalConfig{Timeout: 300, LinkBaseURL: "http://127.0.0.1:8484/", LinkSecret: "0123456789abcdef"})
+	t.Cleanup(func() { Init(config.ApprovalConfig{Timeout: 300}) })
+
+	link := Link("a1b2c3d4", time.Now().Add(time.Minute))
+	if !strings.HasPrefix(link, "http://127.0.0.1:8484/approval/a1b2c3d4?") {
+		t.Fatalf("unexpected link %q", link)
+	}
+	id, expires, sig := parseLink(t, link)
+
+	expired := time.Now().Add(-time.Minute)
+	_, expiredAt, expiredSig := parseLink(t, Link(id, expired))
+
+	later := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name             string
+		id, expires, sig string
+		err              string
+	}{
+		{"valid", id, expires, sig, ""},
+		{"expired", id, expiredAt, expiredSig, "expired"},
+		{"expiry pushed back", id, later, sig, "invalid signature"},
+		{"other request", "deadbeef", expires, sig, "invalid signature"},
+		{"tampered signature", id, expires, strings.Repeat("0", len(sig)), "invalid signature"},
+		{"no signature", id, expires, "", "invalid signature"},
+		{"bad expiry", id, "soon", sig, "invalid expiry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.id, tt.expires, tt.sig)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyOtherSecret(t *testing.T) {
+	Init(config.ApprovalConfig{Timeout: 300, LinkBaseURL: "http://127.0.0.1:8484", LinkSecret: "0123456789abcdef"})
+	id, expires, sig := parseLink(t, Link("a1b2c3d4", time.Now().Add(time.Minute)))
+
+	Init(config.ApprovalConfig{Timeout: 300, LinkBaseURL: "http://127.0.0.1:8484", LinkSecret: "fedcba9876543210"})
+	t.Cleanup(func() { Init(config.ApprovalConfig{Timeout: 300}) })
+
+	if err := Verify(id, expires, sig); err == nil {
+		t.Error("a link signed with another secret must be rejected")
+	}
+}
+
+func TestLinksOff(t *testing.T) {
+	Init(config.ApprovalConfig{Timeout: 300})
+
+	if link := Link("a1b2c3d4", time.Now().Add(time.Minute)); link != "" {
+		t.Errorf("got link %q with links off", link)
+	}
+	if err := Verify("a1b2c3d4", "0", "sig"); err == nil {
+		t.Error("Verify must fail with links off")
+	}
+}
@@ -0,0 +1,71 @@
+package approval
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var promptOut io.Writer // Set while requests are asked for at the terminal
+
+// StartPrompt asks for a decision on every new request at the terminal. An answer of y or n
+// decides the oldest pending request, "y <id>" or "n <id>" a specific one.
+func StartPrompt(in io.Reader, out io.Writer) {
+	mu.Lock()
+	promptOut = out
+	mu.Unlock()
+
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			answer(scanner.Text())
+		}
+	}()
+}
+
+// ask prints the prompt of a new request, callers hold mu.
+func ask(r *Request) {
+	if promptOut == nil {
+		return
+	}
+
+	name := r.LimitedID
+	if r.ItemName != "" {
+		name = fmt.Sprintf("%s (%s)", r.ItemName, r.LimitedID)
+	}
+	fmt.Fprintf(promptOut, "\nApprove buying %s for R$ %d (target R$ %d, %s)? Answer y or n before %s [%s]\n",
+		name, r.Listing.Price, r.Target, r.Reason, r.Expires.Format(time.Kitchen), r.ID)
+}
+
+func answer(line string) {
+	fields := strings.Fields(strings.ToLower(line))
+	if len(fields) == 0 {
+		return
+	}
+
+	id := ""
+	if len(fields) > 1 {
+		id = fields[1]
+	} else if oldest := Pending(); len(oldest) > 0 {
+		id = oldest[0].ID
+	}
+	if id == "" {
+		fmt.Fprintln(promptOut, "No purchase is waiting for approval.")
+		return
+	}
+
+	var err error
+	switch fields[0] {
+	case "y", "yes":
+		err = Approve(id)
+	case "n", "no":
+		err = Deny(id)
+	default:
+		err = fmt.Errorf("answer y or n, optionally followed by the approval id")
+	}
+	if err != nil {
+		fmt.Fprintln(promptOut, err)
+	}
+}
@@ -0,0 +1,390 @@
+package backtest
+
+import (
+	"fmt"
+	"sniper/internal/events"
+	"sniper/internal/parser"
+	"sniper/internal/rules"
+	"sniper/internal/tape"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// A tape without an observation for this long says nothing about the market, e.g. the sniper was stopped.
+const staleAfter = time.Minute
+
+// Reasons a listing the rules would have bought was not bought.
+const (
+	MissPollingGap = "polling_gap" // No poll happened while it was listed
+	MissLostRace   = "lost_race"   // A poll saw it, but it was gone once the purchase would have landed
+	MissBudget     = "budget"      // Only seen while the worker was paused for funds
+)
+
+type Options struct {
+	Dir      string
+	Limiteds []parser.LimitedInfo
+	Interval time.Duration // Poll interval of every worker
+	Latency  time.Duration // Time from the poll that saw the listing to the purchase landing
+	Budget   int           // Robux available, 0 for unlimited
+	Since    time.Time
+	Until    time.Time
+}
+
+type Purchase struct {
+	LimitedID   string    `json:"limited_id"`
+	Time        time.Time `json:"time"`
+	Price       int       `json:"price"`
+	Target      int       `json:"target"`
+	SellerID    int       `json:"seller_id"`
+	UserAssetID int       `json:"user_asset_id"`
+}
+
+type Miss struct {
+	LimitedID   string        `json:"limited_id"`
+	Reason      string        `json:"reason"`
+	Price       int           `json:"price"`
+	Target      int           `json:"target"`
+	SellerID    int           `json:"seller_id"`
+	UserAssetID int           `json:"user_asset_id"`
+	From        time.Time     `json:"from"`
+	Listed      time.Duration `json:"listed"` // How long the listing was visible on the tape
+}
+
+type ItemResult struct {
+	LimitedID string `json:"limited_id"`
+	Target    int    `json:"target"`
+	Records   int    `json:"records"`
+	Polls     int    `json:"polls"`
+	Purchases int    `json:"purchases"`
+	Spent     int    `json:"spent"`
+	Misses    int    `json:"misses"`
+}
+
+type Result struct {
+	Start      time.Time    `json:"start"`
+	End        time.Time    `json:"end"`
+	Interval   string       `json:"interval"`
+	Latency    string       `json:"latency"`
+	Polls      int          `json:"polls"`
+	Items      []ItemResult `json:"items"`
+	Purchases  []Purchase   `json:"purchases"`
+	Misses     []Miss       `json:"misses"`
+	TotalSpent int          `json:"total_spent"`
+	Budget     int          `json:"budget,omitempty"`
+	NoTape     []string     `json:"no_tape,omitempty"` // Watchlist items without recorded listings
+}
+
+// segment is a listing that stayed the cheapest one on the tape from start until end.
+type segment struct {
+	listing events.Listing
+	start   time.Time
+	end     time.Time
+
+	polled      bool // A poll saw it while the worker was running
+	pausedSeen  bool // A poll would have seen it, but the worker was paused for funds
+	bought      bool
+	lostRace    bool
+	qualifying  bool
+	boughtAsset bool // A copy we already bought in the simulation
+}
+
+type item struct {
+	limited  parser.LimitedInfo
+	segments []*segment
+	next     int // First segment that may still contain the poll time
+	inFlight *pending
+	cappedAt time.Time // When the simulated purchases reached max_owned
+	result   *ItemResult
+}
+
+type pending struct {
+	seg   *segment
+	lands time.Time
+}
+
+// Run replays the tape of every watchlist item through the worker's buy rule in simulated time.
+func Run(opts Options) (Result, error) {
+	if opts.Interval <= 0 {
+		return Result{}, fmt.Errorf("poll interval must be greater than 0")
+	}
+
+	result := Result{
+		Interval: opts.Interval.String(),
+		Latency:  opts.Latency.String(),
+		Budget:   opts.Budget,
+	}
+
+	var items, all []*item
+	for _, limited := range opts.Limiteds {
+		// Alert entries never buy, confirmed ones are simulated as if every purchase was approved
+		if limited.Action == parser.ActionAlert {
+			continue
+		}
+
+		it := &item{limited: limited, result: &ItemResult{LimitedID: limited.Id, Target: limited.Price}}
+
+		segments, records, err := load(opts.Dir, limited, opts.Since, opts.Until)
+		if err != nil {
+			return result, err
+		}
+		it.result.Records = records
+		all = append(all, it)
+		if len(segments) == 0 {
+			result.NoTape = append(result.NoTape, limited.Id)
+			continue
+		}
+
+		it.segments = segments
+		if result.Start.IsZero() || segments[0].start.Before(result.Start) {
+			result.Start = segments[0].start
+		}
+		if last := segments[len(segments)-1].end; last.After(result.End) {
+			result.End = last
+		}
+		items = append(items, it)
+	}
+
+	budget := opts.Budget
+	bought := map[string]map[int]bool{} // Limited ID -> user asset ids bought in the simulation
+
+	for now := result.Start; !now.After(result.End); now = now.Add(opts.Interval) {
+		for _, it := range items {
+			// A purchase in flight blocks the worker, the same way InQueue does
+			if it.inFlight != nil {
+				if now.Before(it.inFlight.lands) {
+					continue
+				}
+				land(it, it.inFlight, &budget, opts.Budget > 0, bought, &result)
+				it.inFlight = nil
+			}
+
+			// The worker halts once max_owned is reached
+			if !it.cappedAt.IsZero() {
+				continue
+			}
+
+			seg := it.at(now)
+			if seg == nil {
+				continue
+			}
+
+			// checkFunds: a target above the spendable Robux pauses the worker
+			if opts.Budget > 0 && it.limited.Price > budget {
+				seg.pausedSeen = true
+				continue
+			}
+
+			it.result.Polls++
+			result.Polls++
+			seg.polled = true
+
+			if seg.bought || seg.boughtAsset || !rules.ShouldBuy(it.limited, seg.listing) {
+				continue
+			}
+
+			it.inFlight = &pending{seg: seg, lands: now.Add(opts.Latency)}
+			if opts.Latency <= 0 {
+				land(it, it.inFlight, &budget, opts.Budget > 0, bought, &result)
+				it.inFlight = nil
+			}
+		}
+	}
+
+	for _, it := range items {
+		if it.inFlight != nil {
+			land(it, it.inFlight, &budget, opts.Budget > 0, bought, &result)
+		}
+
+		for _, seg := range it.segments {
+			if !seg.qualifying || seg.bought || seg.boughtAsset || bought[it.limited.Id][seg.listing.UserAssetID] {
+				continue
+			}
+			if !it.cappedAt.IsZero() && !seg.start.Before(it.cappedAt) {
+				continue
+			}
+
+			reason := MissPollingGap
+			switch {
+			case seg.lostRace:
+				reason = MissLostRace
+			case seg.pausedSeen:
+				reason = MissBudget
+			}
+
+			result.Misses = append(result.Misses, Miss{
+				LimitedID:   it.limited.Id,
+				Reason:      reason,
+				Price:       seg.listing.Price,
+				Target:      it.limited.Price,
+				SellerID:    seg.listing.SellerID,
+				UserAssetID: seg.listing.UserAssetID,
+				From:        seg.start,
+				Listed:      seg.end.Sub(seg.start),
+			})
+			it.result.Misses++
+		}
+	}
+
+	for _, it := range all {
+		result.Items = append(result.Items, *it.result)
+	}
+
+	return result, nil
+}
+
+// land settles a purchase: it goes through when the listing is still on the tape when the purchase lands.
+func land(it *item, p *pending, budget *int, limited bool, bought map[string]map[int]bool, result *Result) {
+	// Another item's purchase landed first and took the Robux
+	if limited && p.seg.listing.Price > *budget {
+		p.seg.pausedSeen = true
+		return
+	}
+
+	current := it.at(p.lands)
+	if current == nil || current.listing.UserAssetID != p.seg.listing.UserAssetID {
+		p.seg.lostRace = true
+		return
+	}
+
+	p.seg.bought = true
+	if bought[it.limited.Id] == nil {
+		bought[it.limited.Id] = map[int]bool{}
+	}
+	bought[it.limited.Id][p.seg.listing.UserAssetID] = true
+
+	// The tape keeps showing the copy we "bought", it is not for sale anymore in the simulation
+	for _, seg := range it.segments {
+		if seg.listing.UserAssetID == p.seg.listing.UserAssetID && seg != p.seg {
+			seg.boughtAsset = true
+		}
+	}
+
+	*budget -= p.seg.listing.Price
+	it.result.Purchases++
+	// Owned copies start at zero, only the simulated purchases count towards max_owned
+	if !rules.UnderCap(it.limited, it.result.Purchases) {
+		it.cappedAt = p.lands
+	}
+	it.result.Spent += p.seg.listing.Price
+	result.TotalSpent += p.seg.listing.Price
+	result.Purchases = append(result.Purchases, Purchase{
+		LimitedID:   it.limited.Id,
+		Time:        p.lands,
+		Price:       p.seg.listing.Price,
+		Target:      it.limited.Price,
+		SellerID:    p.seg.listing.SellerID,
+		UserAssetID: p.seg.listing.UserAssetID,
+	})
+}
+
+// at returns the segment visible at t. Lookups of an item only move forward in time
+// (a purchase in flight blocks its polls), so passed segments are skipped for good.
+func (it *item) at(t time.Time) *segment {
+	for it.next < len(it.segments) {
+		seg := it.segments[it.next]
+		if t.Before(seg.start) {
+			return nil
+		}
+		if t.Before(seg.end) || (it.next == len(it.segments)-1 && t.Equal(seg.end)) {
+			return seg
+		}
+		it.next++
+	}
+	return nil
+}
+
+// load turns the recorded listings of an item into segments of unchanged cheapest listing.
+func load(dir string, limited parser.LimitedInfo, since, until time.Time) ([]*segment, int, error) {
+	var segments []*segment
+	var last time.Time
+	var step time.Duration // Recording interval, a listing seen once stays visible for one step
+	records := 0
+
+	err := tape.Read(dir, limited.Id, func(r tape.Record) error {
+		if r.Time.Before(since) || (!until.IsZero() && r.Time.After(until)) {
+			return nil
+		}
+		records++
+
+		listing := events.Listing{Price: r.Price, SellerID: r.SellerID, UserAssetID: r.UserAssetID}
+		if n := len(segments); n > 0 {
+			current := segments[n-1]
+			gap := r.Time.Sub(last)
+
+			switch {
+			case gap > staleAfter:
+				current.end = last.Add(step)
+			case current.listing == listing:
+				step = gap
+				current.end = r.Time
+				last = r.Time
+				return nil
+			default:
+				step = gap
+				current.end = r.Time
+			}
+		}
+
+		segments = append(segments, &segment{
+			listing:    listing,
+			start:      r.Time,
+			end:        r.Time,
+			qualifying: rules.ShouldBuy(limited, listing),
+		})
+		last = r.Time
+		return nil
+	})
+
+	if n := len(segments); n > 0 {
+		segments[n-1].end = last.Add(step)
+	}
+
+	return segments, records, err
+}
+
+// Text renders the result for the terminal.
+func (r Result) Text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Backtest %s to %s, polling every %s with %s purchase latency\n\n", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.Interval, r.Latency)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITEM\tTARGET\tRECORDS\tPOLLS\tBOUGHT\tSPENT\tMISSED")
+	for _, item := range r.Items {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n", item.LimitedID, item.Target, item.Records, item.Polls, item.Purchases, item.Spent, item.Misses)
+	}
+	w.Flush()
+
+	fmt.Fprintf(&b, "\nWould have bought %d listing(s) for R$ %d", len(r.Purchases), r.TotalSpent)
+	if r.Budget > 0 {
+		fmt.Fprintf(&b, " out of a R$ %d budget", r.Budget)
+	}
+	fmt.Fprintln(&b)
+
+	if len(r.Purchases) > 0 {
+		fmt.Fprintln(&b, "\nPurchases:")
+		w = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tITEM\tPRICE\tTARGET\tSELLER\tUSER ASSET")
+		for _, p := range r.Purchases {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", p.Time.Format(time.RFC3339), p.LimitedID, p.Price, p.Target, p.SellerID, p.UserAssetID)
+		}
+		w.Flush()
+	}
+
+	if len(r.Misses) > 0 {
+		fmt.Fprintln(&b, "\nMissed:")
+		w = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LISTED AT\tITEM\tPRICE\tTARGET\tLISTED FOR\tREASON")
+		for _, m := range r.Misses {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", m.From.Format(time.RFC3339), m.LimitedID, m.Price, m.Target, m.Listed.Round(time.Millisecond), m.Reason)
+		}
+		w.Flush()
+	}
+
+	if len(r.NoTape) > 0 {
+		fmt.Fprintf(&b, "\nNo recorded listings for: %s\n", strings.Join(r.NoTape, ", "))
+	}
+
+	return b.String()
+}
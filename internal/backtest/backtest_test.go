@@ -0,0 +1,205 @@
+package backtest
+
+import (
+	"sniper/internal/config"
+	"sniper/internal/parser"
+	"sniper/internal/tape"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// listing is the cheapest listing of an item for one second of the tape.
+type listing struct {
+	price, seller, copy int
+}
+
+// record writes a tape with one record per second for each item.
+func record(t *testing.T, tapes map[string][]listing) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := tape.Start(config.TapeConfig{Directory: dir}); err != nil {
+		t.Fatal(err)
+	}
+	defer tape.Close()
+
+	for id, listings := range tapes {
+		for i, l := range listings {
+			err := tape.Append(tape.Record{
+				Time:        start.Add(time.Duration(i) * time.Second),
+				LimitedID:   id,
+				Price:       l.price,
+				SellerID:    l.seller,
+				UserAssetID: l.copy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return dir
+}
+
+// repeat lists l for n seconds.
+func repeat(l listing, n int) []listing {
+	listings := make([]listing, n)
+	for i := range listings {
+		listings[i] = l
+	}
+	return listings
+}
+
+func concat(parts ...[]listing) []listing {
+	var all []listing
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	return all
+}
+
+func TestRun(t *testing.T) {
+	expensive := listing{price: 600, seller: 1, copy: 10}
+	deal := listing{price: 450, seller: 2, copy: 20}
+	brief := concat(repeat(expensive, 5), repeat(deal, 3), repeat(expensive, 10))
+
+	tests := []struct {
+		name      string
+		tape      []listing
+		limited   parser.LimitedInfo
+		interval  time.Duration
+		latency   time.Duration
+		budget    int
+		purchases int
+		miss      string
+	}{
+		{
+			name:      "bought",
+			tape:      brief,
+			limited:   parser.LimitedInfo{Id: "1234", Price: 500},
+			interval:  time.Second,
+			purchases: 1,
+		},
+		{
+			name:     "target too low",
+			tape:     brief,
+			limited:  parser.LimitedInfo{Id: "1234", Price: 400},
+			interval: time.Second,
+		},
+		{
+			name:     "polled before and after the deal",
+			tape:     brief,
+			limited:  parser.LimitedInfo{Id: "1234", Price: 500},
+			interval: 10 * time.Second,
+			miss:     MissPollingGap,
+		},
+		{
+			name:     "gone before the purchase landed",
+			tape:     brief,
+			limited:  parser.LimitedInfo{Id: "1234", Price: 500},
+			interval: time.Second,
+			latency:  5 * time.Second,
+			miss:     MissLostRace,
+		},
+		{
+			name:     "paused for funds",
+			tape:     brief,
+			limited:  parser.LimitedInfo{Id: "1234", Price: 500},
+			interval: time.Second,
+			budget:   300,
+			miss:     MissBudget,
+		},
+		{
+			name:     "alert entries never buy",
+			tape:     brief,
+			limited:  parser.LimitedInfo{Id: "1234", Price: 500, Action: parser.ActionAlert},
+			interval: time.Second,
+		},
+		{
+			name:      "max_owned stops the worker",
+			tape:      concat(repeat(deal, 3), repeat(listing{price: 480, seller: 3, copy: 30}, 3)),
+			limited:   parser.LimitedInfo{Id: "1234", Price: 500, MaxOwned: 1},
+			interval:  time.Second,
+			purchases: 1,
+		},
+		{
+			name:      "the copy we bought is not bought again",
+			tape:      concat(repeat(deal, 3), repeat(expensive, 3), repeat(deal, 3)),
+			limited:   parser.LimitedInfo{Id: "1234", Price: 500},
+			interval:  time.Second,
+			purchases: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := record(t, map[string][]listing{"1234": tt.tape})
+
+			result, err := Run(Options{
+				Dir:      dir,
+				Limiteds: []parser.LimitedInfo{tt.limited},
+				Interval: tt.interval,
+				Latency:  tt.latency,
+				Budget:   tt.budget,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result.Purchases) != tt.purchases {
+				t.Errorf("got purchases %+v, want %d", result.Purchases, tt.purchases)
+			}
+			for _, p := range result.Purchases {
+				if p.Price > tt.limited.Price {
+					t.Errorf("bought at R$ %d above the target of R$ %d", p.Price, tt.limited.Price)
+				}
+			}
+
+			switch {
+			case tt.miss == "" && len(result.Misses) > 0:
+				t.Errorf("unexpected misses %+v", result.Misses)
+			case tt.miss != "" && (len(result.Misses) != 1 || result.Misses[0].Reason != tt.miss):
+				t.Errorf("got misses %+v, want one %s", result.Misses, tt.miss)
+			}
+		})
+	}
+}
+
+func TestRunBudgetAcrossItems(t *testing.T) {
+	deal := listing{price: 450, seller: 2, copy: 20}
+	dir := record(t, map[string][]listing{
+		"1111": concat(repeat(listing{price: 600, seller: 1, copy: 10}, 2), repeat(deal, 5)),
+		"2222": concat(repeat(listing{price: 600, seller: 1, copy: 11}, 4), repeat(listing{price: 400, seller: 3, copy: 30}, 3)),
+	})
+
+	result, err := Run(Options{
+		Dir: dir,
+		Limiteds: []parser.LimitedInfo{
+			{Id: "1111", Price: 500},
+			{Id: "2222", Price: 500},
+			{Id: "3333", Price: 500},
+		},
+		Interval: time.Second,
+		Budget:   800,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Purchases) != 1 || result.Purchases[0].LimitedID != "1111" || result.TotalSpent != 450 {
+		t.Errorf("the first deal must use up the budget, got %+v", result.Purchases)
+	}
+	if len(result.Misses) != 1 || result.Misses[0].LimitedID != "2222" || result.Misses[0].Reason != MissBudget {
+		t.Errorf("got misses %+v, want 2222 missed for the budget", result.Misses)
+	}
+	if len(result.NoTape) != 1 || result.NoTape[0] != "3333" {
+		t.Errorf("got no tape %v, want [3333]", result.NoTape)
+	}
+}
+
+func TestRunNeedsAnInterval(t *testing.T) {
+	if _, err := Run(Options{Dir: t.TempDir()}); err == nil {
+		t.Error("a zero interval must be rejected")
+	}
+}
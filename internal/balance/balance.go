@@ -0,0 +1,125 @@
+package balance
+
+import (
+	"sniper/internal/logging"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("balance")
+
+const DefaultRefreshInterval = 60 * time.Second
+
+var (
+	robux     int
+	known     bool
+	reserve   int
+	listeners []func(balance int)
+	mu        sync.RWMutex
+	refresh   = make(chan struct{}, 1)
+)
+
+// Start fetches the balance right away, then keeps it fresh every interval and whenever Refresh is called.
+// Robux below the reserve are never spent.
+func Start(interval time.Duration, keep int) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+
+	mu.Lock()
+	reserve = keep
+	mu.Unlock()
+
+	fetch()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-refresh:
+			}
+			fetch()
+		}
+	}()
+}
+
+func fetch() {
+	// Without a session the request can only fail
+	if !session.Active() {
+		return
+	}
+
+	amount, err := scraper.FetchBalance(session.Cookie(), session.User().Id)
+	if err != nil {
+		logger.Error("Could not fetch Robux balance", "Error", err)
+		return
+	}
+	Set(amount)
+}
+
+// Refresh asks for a new balance as soon as possible, it never blocks.
+func Refresh() {
+	select {
+	case refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Set records a balance we learned about elsewhere (e.g. the balance after a purchase).
+func Set(amount int) {
+	mu.Lock()
+	changed := !known || robux != amount
+	robux = amount
+	known = true
+	notify := append([]func(int){}, listeners...)
+	mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	logger.Info("💰 Robux Balance", "Robux", amount)
+	for _, listener := range notify {
+		listener(amount)
+	}
+}
+
+// Get returns the last known balance and whether it has been fetched at all.
+func Get() (int, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return robux, known
+}
+
+// Spendable is the balance minus the configured reserve.
+func Spendable() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return robux - reserve
+}
+
+// CanAfford reports whether a purchase at price fits in the spendable balance.
+// Until the balance is known every price is considered affordable.
+func CanAfford(price int) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return !known || price <= robux-reserve
+}
+
+// OnChange registers a callback that runs every time the balance changes,
+// and right away when the balance is already known.
+func OnChange(listener func(balance int)) {
+	mu.Lock()
+	listeners = append(listeners, listener)
+	current, ready := robux, known
+	mu.Unlock()
+
+	if ready {
+		listener(current)
+	}
+}
@@ -2,53 +2,232 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sync"
 
 	"gopkg.in/yaml.v2"
 )
 
+// ConfigStruct is the whole config file. `default` tags document (and apply) the value
+// used when a key is missing, `secret` tags mark values that are redacted when printed.
+// Every field can be overridden through SNIPER_<YAML PATH>, e.g. SNIPER_DIGEST_INTERVAL.
 type ConfigStruct struct {
-	Verbose    bool   `yaml:"verbose"`
-	Cookie     string `yaml:"cookie"`
-	WebhookURL string `yaml:"webhook_url"`
-	Rate       int    `yaml:"rate_limit_time_ms"`
+	Verbose     bool              `yaml:"verbose"`
+	Log         LogConfig         `yaml:"log"`
+	Cookie      string            `yaml:"cookie" secret:"true"`
+	WebhookURL  string            `yaml:"webhook_url" secret:"true"`
+	Webhooks    []WebhookSink     `yaml:"webhooks"`
+	NotifyQueue NotifyQueueConfig `yaml:"notify_queue"`
+	Templates   map[string]string `yaml:"templates"`
+	EventLog    string            `yaml:"event_log" default:"events.jsonl"`
+	Digest      DigestConfig      `yaml:"digest"`
+	Tape        TapeConfig        `yaml:"tape"`
+	Balance     BalanceConfig     `yaml:"balance"`
+	Inventory   InventoryConfig   `yaml:"inventory"`
+	Resell      ResellConfig      `yaml:"resell"`
+	Listings    ListingsConfig    `yaml:"listings"`
+	Drop        DropConfig        `yaml:"drop"`
+	Discovery   DiscoveryConfig   `yaml:"discovery"`
+	Control     ControlConfig     `yaml:"control"`
+	Alerts      AlertsConfig      `yaml:"alerts"`
+	Approval    ApprovalConfig    `yaml:"approval"`
+	Session     SessionConfig     `yaml:"session"`
+	Secrets     SecretsConfig     `yaml:"secrets"`
+	Rate        int               `yaml:"rate_limit_time_ms" default:"500"`
+}
+
+// LogConfig sets the log output. Format is pretty or json, Levels overrides
+// the level of single subsystems (worker, scraper, purchase, webhook, ...).
+type LogConfig struct {
+	Format string            `yaml:"format" default:"pretty"`
+	Level  string            `yaml:"level" default:"info"`
+	Levels map[string]string `yaml:"levels"`
+}
+
+// NotifyQueueConfig tunes the background webhook queue.
+type NotifyQueueConfig struct {
+	Size       int    `yaml:"size" default:"100"`
+	MaxRetries int    `yaml:"max_retries" default:"5"`
+	SpoolPath  string `yaml:"spool_path" default:"notifications.json"`
+}
+
+// DigestConfig schedules the session digest reports.
+// Interval is hourly, daily or a Go duration (e.g. 30m), empty disables the digest.
+type DigestConfig struct {
+	Interval        string  `yaml:"interval"`
+	NearMissPercent float64 `yaml:"near_miss_percent" default:"10"`
+	Directory       string  `yaml:"directory" default:"reports"`
+}
+
+// TapeConfig controls the price tape, every observed listing recorded per item.
+// An empty Directory disables the tape.
+type TapeConfig struct {
+	Directory string `yaml:"directory" default:"tape"`
+	MaxSizeMB int    `yaml:"max_size_mb" default:"16"` // Size at which an item's file is rotated
+	MaxFiles  int    `yaml:"max_files" default:"5"`    // Rotated files kept per item, older ones are deleted
+}
+
+// BalanceConfig controls the Robux balance monitor.
+// Workers whose target is above the balance minus Reserve are paused.
+type BalanceConfig struct {
+	RefreshInterval int `yaml:"refresh_interval_s" default:"60"`
+	Reserve         int `yaml:"reserve"`
+}
+
+// InventoryConfig controls how often the owned collectibles are fetched, they are also refreshed after every purchase.
+type InventoryConfig struct {
+	RefreshInterval int `yaml:"refresh_interval_s" default:"300"`
+}
+
+// ResellConfig controls how sniped copies with a resell rule are put back on sale.
+// Pending copies are kept in StateFile so hold periods survive restarts.
+type ResellConfig struct {
+	CheckInterval int    `yaml:"check_interval_s" default:"600"`
+	StateFile     string `yaml:"state_file" default:"resell.json"`
+	MaxAttempts   int    `yaml:"max_attempts" default:"5"`
+}
+
+// ListingsConfig controls the listing manager, which reports our resale listings as they sell
+// and, with Reprice, lowers them to stay the cheapest listing down to each item's floor.
+type ListingsConfig struct {
+	Enabled       bool    `yaml:"enabled"`
+	CheckInterval int     `yaml:"check_interval_s" default:"120"`
+	Reprice       bool    `yaml:"reprice"`
+	Undercut      int     `yaml:"undercut" default:"1"`     // Robux below the cheapest competing listing
+	FeePercent    float64 `yaml:"fee_percent" default:"30"` // Marketplace fee taken from every sale
+	StateFile     string  `yaml:"state_file" default:"listings.json"`
+}
+
+// DropConfig paces the workers of new drops (limiteds file entries with a release time).
+// The sale status is checked every IdleInterval, faster and faster over the RampWindow before
+// the release, then every FastInterval until HotPeriod after it.
+type DropConfig struct {
+	IdleInterval int `yaml:"idle_interval_s" default:"60"`
+	RampWindow   int `yaml:"ramp_window_s" default:"600"`
+	FastInterval int `yaml:"fast_interval_ms" default:"500"`
+	HotPeriod    int `yaml:"hot_period_s" default:"900"`
+}
+
+// AlertsConfig tunes the alerts of action=alert and action=alert-then-confirm entries. The same
+// listing is reported again only after Cooldown minutes, a new listing right away.
+type AlertsConfig struct {
+	Cooldown int `yaml:"cooldown_m" default:"30"`
+}
+
+// ApprovalConfig controls purchases held for approval, a request not decided within Timeout
+// seconds is dropped. Buy entries listed at MinPrice or more need an approval too. Requests can be
+// decided at the terminal (Prompt), through the control API or with a link signed with LinkSecret.
+type ApprovalConfig struct {
+	Timeout     int    `yaml:"timeout_s" default:"300"`
+	MinPrice    int    `yaml:"min_price"` // 0 never holds buy entries
+	Prompt      bool   `yaml:"prompt" default:"true"`
+	LinkBaseURL string `yaml:"link_base_url"` // Where the control API is reachable from, empty for no links
+	LinkSecret  string `yaml:"link_secret" secret:"true"`
+}
+
+// ControlConfig serves the control API, and with Dashboard the web dashboard, on a loopback address.
+type ControlConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	Listen        string `yaml:"listen" default:"127.0.0.1:8484"`
+	Dashboard     bool   `yaml:"dashboard" default:"true"`
+	HistoryPoints int    `yaml:"history_points" default:"500"` // Prices kept per item for the charts
+}
+
+// DiscoveryConfig controls catalog discovery, which searches collectibles for items listed at least
+// MinDiscount percent below their RAP. Action alert only notifies, watch also adds them to the
+// watchlist for WatchFor minutes with a target of TargetPercent of the RAP.
+type DiscoveryConfig struct {
+	Enabled       bool    `yaml:"enabled"`
+	Interval      int     `yaml:"interval_s" default:"300"`
+	Category      string  `yaml:"category"`
+	Subcategory   string  `yaml:"subcategory"`
+	Creator       string  `yaml:"creator"`
+	Keyword       string  `yaml:"keyword"`
+	MinPrice      int     `yaml:"min_price"`
+	MaxPrice      int     `yaml:"max_price"`
+	Pages         int     `yaml:"pages" default:"1"`
+	MinDiscount   float64 `yaml:"min_discount_percent" default:"30"`
+	Action        string  `yaml:"action" default:"alert"`
+	TargetPercent float64 `yaml:"target_percent" default:"70"`
+	WatchFor      int     `yaml:"watch_for_m" default:"60"`
+	MaxWatched    int     `yaml:"max_watched" default:"5"` // Discovered items watched at the same time
+	MaxOwned      int     `yaml:"max_owned" default:"1"`   // Copies of a discovered item bought at most
+}
+
+// SessionConfig controls how often the cookie is verified while running.
+type SessionConfig struct {
+	CheckInterval int `yaml:"check_interval_s" default:"60"`
+}
+
+// SecretsConfig points at the files the cookie and webhook URL can be loaded from,
+// instead of keeping them in this file.
+type SecretsConfig struct {
+	File          string `yaml:"file"`
+	EncryptedFile string `yaml:"encrypted_file"`
+	CookiesTxt    string `yaml:"cookies_txt"`
+}
+
+// WebhookSink is a single notification destination.
+// Type is one of discord, slack, telegram, json or ntfy.
+type WebhookSink struct {
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url" secret:"true"`
+	Events  []string          `yaml:"events"`
+	Token   string            `yaml:"token" secret:"true"`
+	ChatID  string            `yaml:"chat_id"`
+	Headers map[string]string `yaml:"headers" secret:"true"`
 }
 
 var (
 	config     *ConfigStruct
 	configOnce sync.Once
+	configMu   sync.RWMutex
 )
 
+// readConfig decodes the YAML file at path.
+func readConfig(path string) (*ConfigStruct, error) {
+	// Check if the file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file does not exist: %s", path)
+	}
+
+	// Open the config file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %v", err)
+	}
+	defer f.Close()
+
+	// Start from the defaults, keys present in the file replace them
+	c := Defaults()
+	decoder := yaml.NewDecoder(f)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode config: %v", err)
+	}
+
+	if err := applyEnv(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
 // LoadConfig reads the config file from the specified path and caches the result.
 func LoadConfig(path string) (*ConfigStruct, error) {
 	var err error
 
 	configOnce.Do(func() {
-		// Check if the file exists
-		if _, err = os.Stat(path); os.IsNotExist(err) {
-			err = fmt.Errorf("config file does not exist: %s", path)
-			return
-		}
-
-		// Open the config file
-		f, openErr := os.Open(path)
-		if openErr != nil {
-			err = fmt.Errorf("failed to open config file: %v", openErr)
-			return
-		}
-		defer f.Close()
-
-		// Create a new config struct and decode the YAML file
-		c := &ConfigStruct{}
-		decoder := yaml.NewDecoder(f)
-		if decodeErr := decoder.Decode(c); decodeErr != nil {
-			err = fmt.Errorf("failed to decode config: %v", decodeErr)
+		var c *ConfigStruct
+		c, err = readConfig(path)
+		if err != nil {
 			return
 		}
 
 		// Cache the successfully loaded configuration
+		configMu.Lock()
 		config = c
+		configMu.Unlock()
 	})
 
 	// If any error occurred during initialization, return it
@@ -56,5 +235,22 @@ func LoadConfig(path string) (*ConfigStruct, error) {
 		return nil, err
 	}
 
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config, nil
 }
+
+// Reload reads the config file again and replaces the cached result.
+// Structs handed out earlier are left untouched, callers pick up the new values themselves.
+func Reload(path string) (*ConfigStruct, error) {
+	c, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	configMu.Lock()
+	config = c
+	configMu.Unlock()
+
+	return c, nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sniper/internal/redact"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix starts the name of every environment override.
+const EnvPrefix = "SNIPER_"
+
+// Redacted replaces secret values in printed configs.
+const Redacted = redact.Mask
+
+// walk calls fn for every leaf field of the struct v points at, with its yaml path.
+// Nested config structs are walked into, lists and maps are leaves.
+func walk(v reflect.Value, path []string, fn func(field reflect.StructField, value reflect.Value, path []string) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldPath := append(append([]string{}, path...), name)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := walk(value, fieldPath, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(field, value, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setString parses raw into a scalar field, lists and maps are parsed as YAML.
+func setString(value reflect.Value, raw string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(parsed)
+	default:
+		return yaml.Unmarshal([]byte(raw), value.Addr().Interface())
+	}
+
+	return nil
+}
+
+// Defaults returns a config holding the value of every `default` tag.
+func Defaults() *ConfigStruct {
+	c := &ConfigStruct{}
+
+	err := walk(reflect.ValueOf(c).Elem(), nil, func(field reflect.StructField, value reflect.Value, path []string) error {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			return setString(value, def)
+		}
+		return nil
+	})
+	if err != nil {
+		// Only a typo in a struct tag can get us here
+		panic(fmt.Sprintf("invalid default tag: %v", err))
+	}
+
+	return c
+}
+
+// EnvName is the environment variable that overrides the field at path.
+func EnvName(path []string) string {
+	return EnvPrefix + strings.ToUpper(strings.Join(path, "_"))
+}
+
+// applyEnv overrides fields from SNIPER_* environment variables.
+func applyEnv(c *ConfigStruct) error {
+	return walk(reflect.ValueOf(c).Elem(), nil, func(field reflect.StructField, value reflect.Value, path []string) error {
+		raw, ok := os.LookupEnv(EnvName(path))
+		if !ok {
+			return nil
+		}
+
+		if err := setString(value, raw); err != nil {
+			return fmt.Errorf("invalid value for %s (%s): %w", EnvName(path), strings.Join(path, "."), err)
+		}
+		return nil
+	})
+}
+
+// Secrets returns every non-empty `secret` value of the config, so they can be masked in output.
+func Secrets(c *ConfigStruct) []string {
+	var values []string
+	collect := func(field reflect.StructField, value reflect.Value, path []string) error {
+		if field.Tag.Get("secret") != "true" {
+			return nil
+		}
+
+		switch value.Kind() {
+		case reflect.String:
+			values = append(values, value.String())
+		case reflect.Map:
+			for _, key := range value.MapKeys() {
+				values = append(values, fmt.Sprint(value.MapIndex(key).Interface()))
+			}
+		}
+		return nil
+	}
+
+	walk(reflect.ValueOf(c).Elem(), nil, collect)
+	for i := range c.Webhooks {
+		walk(reflect.ValueOf(&c.Webhooks[i]).Elem(), nil, collect)
+	}
+
+	return values
+}
+
+// Redact returns a copy of the config with every `secret` value replaced, for printing.
+func Redact(c *ConfigStruct) *ConfigStruct {
+	out := *c
+	out.Webhooks = append([]WebhookSink{}, c.Webhooks...)
+
+	redactStruct(reflect.ValueOf(&out).Elem())
+	for i := range out.Webhooks {
+		redactStruct(reflect.ValueOf(&out.Webhooks[i]).Elem())
+	}
+
+	return &out
+}
+
+func redactStruct(v reflect.Value) {
+	walk(v, nil, func(field reflect.StructField, value reflect.Value, path []string) error {
+		if field.Tag.Get("secret") != "true" || value.IsZero() {
+			return nil
+		}
+
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(Redacted)
+		case reflect.Map:
+			// Replace the map, the original one is shared with the caller
+			redacted := reflect.MakeMap(value.Type())
+			for _, key := range value.MapKeys() {
+				redacted.SetMapIndex(key, reflect.ValueOf(Redacted))
+			}
+			value.Set(redacted)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sniper/internal/logging"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// FieldError is a single problem with the config, Path is the yaml path of the field.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationError holds every problem found, so they can all be fixed in one go.
+type ValidationError []FieldError
+
+func (v ValidationError) Error() string {
+	lines := make([]string, 0, len(v))
+	for _, field := range v {
+		lines = append(lines, "  - "+field.Error())
+	}
+	return fmt.Sprintf("invalid config, %d problem(s):\n%s", len(v), strings.Join(lines, "\n"))
+}
+
+var (
+	validators   []func(*ConfigStruct) []FieldError
+	validatorsMu sync.Mutex
+)
+
+// AddValidator lets a package check the config section it owns.
+func AddValidator(validator func(*ConfigStruct) []FieldError) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators = append(validators, validator)
+}
+
+// Validate checks the whole config and returns a ValidationError listing every problem.
+// Credentials have to be resolved (see the secrets package) before calling it.
+func Validate(c *ConfigStruct) error {
+	var problems []FieldError
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Cookie == "" {
+		add("cookie", "is empty, set it here, in a secrets file or through %s", EnvName([]string{"cookie"}))
+	}
+	if c.Rate <= 0 {
+		add("rate_limit_time_ms", "must be greater than 0, got %d", c.Rate)
+	}
+	if c.WebhookURL == "" && len(c.Webhooks) == 0 {
+		add("webhook_url", "no webhook configured, set webhook_url or add an entry to webhooks")
+	}
+
+	if _, err := logging.ParseFormat(c.Log.Format); err != nil {
+		add("log.format", "%v", err)
+	}
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		add("log.level", "unknown level %q", c.Log.Level)
+	}
+	for subsystem, level := range c.Log.Levels {
+		if _, err := log.ParseLevel(level); err != nil {
+			add("log.levels."+subsystem, "unknown level %q", level)
+		}
+	}
+
+	if c.NotifyQueue.Size <= 0 {
+		add("notify_queue.size", "must be greater than 0, got %d", c.NotifyQueue.Size)
+	}
+	if c.NotifyQueue.MaxRetries < 0 {
+		add("notify_queue.max_retries", "must not be negative, got %d", c.NotifyQueue.MaxRetries)
+	}
+	if c.NotifyQueue.SpoolPath == "" {
+		add("notify_queue.spool_path", "is empty")
+	}
+
+	if c.EventLog == "" {
+		add("event_log", "is empty")
+	}
+	if c.Digest.NearMissPercent < 0 {
+		add("digest.near_miss_percent", "must not be negative, got %v", c.Digest.NearMissPercent)
+	}
+
+	if c.Tape.Directory != "" {
+		if c.Tape.MaxSizeMB <= 0 {
+			add("tape.max_size_mb", "must be greater than 0, got %d", c.Tape.MaxSizeMB)
+		}
+		if c.Tape.MaxFiles < 0 {
+			add("tape.max_files", "must not be negative, got %d", c.Tape.MaxFiles)
+		}
+	}
+
+	if c.Balance.RefreshInterval <= 0 {
+		add("balance.refresh_interval_s", "must be greater than 0, got %d", c.Balance.RefreshInterval)
+	}
+	if c.Balance.Reserve < 0 {
+		add("balance.reserve", "must not be negative, got %d", c.Balance.Reserve)
+	}
+
+	if c.Inventory.RefreshInterval <= 0 {
+		add("inventory.refresh_interval_s", "must be greater than 0, got %d", c.Inventory.RefreshInterval)
+	}
+
+	if c.Resell.CheckInterval <= 0 {
+		add("resell.check_interval_s", "must be greater than 0, got %d", c.Resell.CheckInterval)
+	}
+	if c.Resell.MaxAttempts <= 0 {
+		add("resell.max_attempts", "must be greater than 0, got %d", c.Resell.MaxAttempts)
+	}
+
+	if c.Listings.Enabled {
+		if c.Listings.CheckInterval <= 0 {
+			add("listings.check_interval_s", "must be greater than 0, got %d", c.Listings.CheckInterval)
+		}
+		if c.Listings.Undercut < 1 {
+			add("listings.undercut", "must be at least 1, got %d", c.Listings.Undercut)
+		}
+		if c.Listings.FeePercent < 0 || c.Listings.FeePercent >= 100 {
+			add("listings.fee_percent", "must be between 0 and 100, got %v", c.Listings.FeePercent)
+		}
+	}
+
+	if c.Drop.IdleInterval <= 0 {
+		add("drop.idle_interval_s", "must be greater than 0, got %d", c.Drop.IdleInterval)
+	}
+	if c.Drop.RampWindow < 0 {
+		add("drop.ramp_window_s", "must not be negative, got %d", c.Drop.RampWindow)
+	}
+	if c.Drop.FastInterval <= 0 {
+		add("drop.fast_interval_ms", "must be greater than 0, got %d", c.Drop.FastInterval)
+	} else if c.Drop.IdleInterval > 0 && time.Duration(c.Drop.FastInterval)*time.Millisecond > time.Duration(c.Drop.IdleInterval)*time.Second {
+		add("drop.fast_interval_ms", "must not be slower than drop.idle_interval_s")
+	}
+	if c.Drop.HotPeriod < 0 {
+		add("drop.hot_period_s", "must not be negative, got %d", c.Drop.HotPeriod)
+	}
+
+	if c.Discovery.Enabled {
+		d := c.Discovery
+		if d.Interval <= 0 {
+			add("discovery.interval_s", "must be greater than 0, got %d", d.Interval)
+		}
+		if d.Pages < 1 || d.Pages > 10 {
+			add("discovery.pages", "must be between 1 and 10, got %d", d.Pages)
+		}
+		if d.MinPrice < 0 || d.MaxPrice < 0 || (d.MaxPrice > 0 && d.MaxPrice < d.MinPrice) {
+			add("discovery.max_price", "must be 0 or at least min_price, got %d-%d", d.MinPrice, d.MaxPrice)
+		}
+		if d.MinDiscount < 0 || d.MinDiscount >= 100 {
+			add("discovery.min_discount_percent", "must be between 0 and 100, got %v", d.MinDiscount)
+		}
+		switch d.Action {
+		case "alert":
+		case "watch":
+			if d.TargetPercent <= 0 || d.TargetPercent > 100 {
+				add("discovery.target_percent", "must be between 0 and 100, got %v", d.TargetPercent)
+			}
+			if d.WatchFor <= 0 {
+				add("discovery.watch_for_m", "must be greater than 0, got %d", d.WatchFor)
+			}
+			if d.MaxWatched <= 0 {
+				add("discovery.max_watched", "must be greater than 0, got %d", d.MaxWatched)
+			}
+			if d.MaxOwned <= 0 {
+				add("discovery.max_owned", "must be greater than 0, got %d", d.MaxOwned)
+			}
+		default:
+			add("discovery.action", "must be alert or watch, got %q", d.Action)
+		}
+	}
+
+	if c.Alerts.Cooldown <= 0 {
+		add("alerts.cooldown_m", "must be greater than 0, got %d", c.Alerts.Cooldown)
+	}
+	if c.Approval.Timeout <= 0 {
+		add("approval.timeout_s", "must be greater than 0, got %d", c.Approval.Timeout)
+	}
+	if c.Approval.MinPrice < 0 {
+		add("approval.min_price", "must be 0 or positive, got %d", c.Approval.MinPrice)
+	}
+	if c.Approval.LinkBaseURL != "" {
+		if !strings.HasPrefix(c.Approval.LinkBaseURL, "http://") && !strings.HasPrefix(c.Approval.LinkBaseURL, "https://") {
+			add("approval.link_base_url", "must start with http:// or https://, got %q", c.Approval.LinkBaseURL)
+		}
+		if !c.Control.Enabled {
+			add("approval.link_base_url", "approval links are served by the control API, enable control")
+		}
+		if len(c.Approval.LinkSecret) < 16 {
+			add("approval.link_secret", "must be at least 16 characters when approval links are on")
+		}
+	}
+
+	if c.Control.Enabled {
+		// Anyone who can reach the API can change targets, it is never exposed beyond the machine
+		host, _, err := net.SplitHostPort(c.Control.Listen)
+		if ip := net.ParseIP(host); err != nil || (host != "localhost" && (ip == nil || !ip.IsLoopback())) {
+			add("control.listen", "must be a loopback address like 127.0.0.1:8484, got %q", c.Control.Listen)
+		}
+		if c.Control.HistoryPoints <= 0 {
+			add("control.history_points", "must be greater than 0, got %d", c.Control.HistoryPoints)
+		}
+	}
+
+	if c.Session.CheckInterval <= 0 {
+		add("session.check_interval_s", "must be greater than 0, got %d", c.Session.CheckInterval)
+	}
+
+	validatorsMu.Lock()
+	registered := append([]func(*ConfigStruct) []FieldError{}, validators...)
+	validatorsMu.Unlock()
+
+	for _, validator := range registered {
+		problems = append(problems, validator(c)...)
+	}
+
+	if len(problems) > 0 {
+		return ValidationError(problems)
+	}
+
+	return nil
+}
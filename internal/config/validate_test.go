@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// valid is the default config with the credentials filled in.
+func valid() *ConfigStruct {
+	c := Defaults()
+	c.Cookie = "cookie"
+	c.WebhookURL = "https://discord.com/api/webhooks/1/token"
+	return c
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if err := Validate(valid()); err != nil {
+		t.Fatalf("the defaults must be valid: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*ConfigStruct)
+		paths  []string
+	}{
+		{"no cookie", func(c *ConfigStruct) { c.Cookie = "" }, []string{"cookie"}},
+		{"no webhook", func(c *ConfigStruct) { c.WebhookURL = "" }, []string{"webhook_url"}},
+		{"no rate", func(c *ConfigStruct) { c.Rate = 0 }, []string{"rate_limit_time_ms"}},
+		{"log level", func(c *ConfigStruct) { c.Log.Levels = map[string]string{"worker": "loud"} }, []string{"log.levels.worker"}},
+		{"tape off skips its limits", func(c *ConfigStruct) { c.Tape.Directory = ""; c.Tape.MaxSizeMB = 0 }, nil},
+		{"tape size", func(c *ConfigStruct) { c.Tape.MaxSizeMB = 0 }, []string{"tape.max_size_mb"}},
+		{"fee", func(c *ConfigStruct) { c.Listings.Enabled = true; c.Listings.FeePercent = 100 }, []string{"listings.fee_percent"}},
+		{"fast drop polls slower than idle", func(c *ConfigStruct) { c.Drop.IdleInterval = 1; c.Drop.FastInterval = 2000 }, []string{"drop.fast_interval_ms"}},
+		{"discovery action", func(c *ConfigStruct) { c.Discovery.Enabled = true; c.Discovery.Action = "buy" }, []string{"discovery.action"}},
+		{"control on every interface", func(c *ConfigStruct) { c.Control.Enabled = true; c.Control.Listen = "0.0.0.0:8484" }, []string{"control.listen"}},
+		{"control on localhost", func(c *ConfigStruct) { c.Control.Enabled = true; c.Control.Listen = "localhost:8484" }, nil},
+		{
+			"approval links without control",
+			func(c *ConfigStruct) {
+				c.Approval.LinkBaseURL = "https://sniper.example"
+				c.Approval.LinkSecret = "short"
+			},
+			[]string{"approval.link_base_url", "approval.link_secret"},
+		},
+		{
+			"every problem at once",
+			func(c *ConfigStruct) { c.Cookie = ""; c.Rate = -1; c.Session.CheckInterval = 0 },
+			[]string{"cookie", "rate_limit_time_ms", "session.check_interval_s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.change(c)
+
+			err := Validate(c)
+			if len(tt.paths) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+
+			var problems ValidationError
+			if !errors.As(err, &problems) {
+				t.Fatalf("got %v, want a ValidationError", err)
+			}
+			if len(problems) != len(tt.paths) {
+				t.Fatalf("got problems %v, want %v", problems, tt.paths)
+			}
+			for i, path := range tt.paths {
+				if problems[i].Path != path {
+					t.Errorf("problem %d is about %s, want %s", i, problems[i].Path, path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"sniper/internal/logging"
+	"syscall"
+	"time"
+)
+
+var logger = logging.For("config")
+
+// How often the config file is checked for changes.
+const WatchInterval = 5 * time.Second
+
+// Watch reloads the config whenever the file changes on disk or the process gets a SIGHUP,
+// and hands the new config to onReload. It blocks so call it in its own goroutine.
+func Watch(path string, onReload func(*ConfigStruct)) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var modified time.Time
+	if info, err := os.Stat(path); err == nil {
+		modified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hangup:
+			logger.Info("SIGHUP received, reloading configuration.")
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(modified) {
+				continue
+			}
+			modified = info.ModTime()
+			logger.Info("Configuration file changed, reloading.")
+		}
+
+		c, err := Reload(path)
+		if err != nil {
+			logger.Error("Could not reload configuration, keeping the old one.", "Error", err)
+			continue
+		}
+
+		onReload(c)
+	}
+}
@@ -0,0 +1,111 @@
+package control
+
+import (
+	"net/http"
+	"sniper/internal/approval"
+
+	"github.com/goccy/go-json"
+)
+
+func listItems(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, Watchlist())
+}
+
+func addItem(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Line string `json:"line"` // Same format as the limiteds file
+	}
+	if !ReadJSON(w, r, &body) {
+		return
+	}
+
+	limited, err := Add(body.Line)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, limited)
+}
+
+func editItem(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Price int `json:"price"`
+	}
+	if !ReadJSON(w, r, &body) {
+		return
+	}
+
+	limited, err := SetTarget(r.PathValue("id"), body.Price)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, limited)
+}
+
+func removeItem(w http.ResponseWriter, r *http.Request) {
+	if err := Remove(r.PathValue("id")); err != nil {
+		WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pauseItem(w http.ResponseWriter, r *http.Request) {
+	if err := Pause(r.PathValue("id")); err != nil {
+		WriteError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func resumeItem(w http.ResponseWriter, r *http.Request) {
+	if err := Resume(r.PathValue("id")); err != nil {
+		WriteError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func listApprovals(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, approval.Pending())
+}
+
+func approve(w http.ResponseWriter, r *http.Request) {
+	if err := approval.Approve(r.PathValue("id")); err != nil {
+		WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deny(w http.ResponseWriter, r *http.Request) {
+	if err := approval.Deny(r.PathValue("id")); err != nil {
+		WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReadJSON decodes a request body, it answers the request itself when the body is invalid.
+func ReadJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(v); err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// WriteJSON answers with v encoded as JSON.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Debug("Could not write response", "Error", err)
+	}
+}
+
+// WriteError answers with {"error": "..."}.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,181 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sniper/internal/config"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/worker"
+	"strings"
+	"time"
+)
+
+var logger = logging.For("control")
+
+// mux holds the routes of the API and of anything registered with Handle before Start.
+var mux = http.NewServeMux()
+
+// Item is a watched item as the API shows it.
+type Item struct {
+	parser.LimitedInfo
+	Paused bool `json:"paused"` // Paused by hand
+}
+
+func init() {
+	mux.HandleFunc("GET /api/watchlist", listItems)
+	mux.HandleFunc("POST /api/watchlist", addItem)
+	mux.HandleFunc("PUT /api/watchlist/{id}", editItem)
+	mux.HandleFunc("DELETE /api/watchlist/{id}", removeItem)
+	mux.HandleFunc("POST /api/watchlist/{id}/pause", pauseItem)
+	mux.HandleFunc("POST /api/watchlist/{id}/resume", resumeItem)
+	mux.HandleFunc("GET /api/approvals", listApprovals)
+	mux.HandleFunc("POST /api/approvals/{id}/approve", approve)
+	mux.HandleFunc("POST /api/approvals/{id}/deny", deny)
+	mux.HandleFunc("GET /approval/{id}", approvalLink)
+	mux.HandleFunc("POST /approval/{id}", decideLink)
+}
+
+// Handle adds a route next to the API, e.g. the dashboard's pages. It must be called before Start.
+func Handle(pattern string, handler http.Handler) {
+	mux.Handle(pattern, handler)
+}
+
+// Start serves the API on the configured loopback address.
+func Start(cfg config.ControlConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", cfg.Listen, err)
+	}
+
+	server := &http.Server{
+		Handler:           guard(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("Control API listening", "Address", "http://"+listener.Addr().String())
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Control API stopped", "Error", err)
+		}
+	}()
+
+	return nil
+}
+
+// guard only lets local requests through. Pages served by other sites can reach a loopback
+// address too, so the Host must be local (against DNS rebinding) and changes must be sent as
+// JSON, which browsers never do across origins without a preflight the API does not answer.
+// Approval links carry their own signature and may come through a proxy from anywhere.
+func guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/approval/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			http.Error(w, "changes must be sent as application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Watchlist returns the watched items in the order they were added.
+func Watchlist() []Item {
+	limiteds := worker.Watched()
+	items := make([]Item, 0, len(limiteds))
+	for _, limited := range limiteds {
+		items = append(items, Item{LimitedInfo: limited, Paused: worker.Held(limited.Id)})
+	}
+	return items
+}
+
+// Add watches a new item, given as a line of the limiteds file (e.g. "1234, 500, max_owned=2").
+// Items added at runtime are not written back to the file.
+func Add(line string) (parser.LimitedInfo, error) {
+	limited, err := parser.ParseLine(line)
+	if err != nil {
+		return parser.LimitedInfo{}, err
+	}
+	if err := worker.Add(limited); err != nil {
+		return parser.LimitedInfo{}, err
+	}
+
+	logger.Info("Item added", "Limited ID", limited.Id, "Target", limited.Price)
+	return limited, nil
+}
+
+// SetTarget changes the target of a watched item, its other options are kept.
+func SetTarget(id string, price int) (parser.LimitedInfo, error) {
+	if price <= 0 {
+		return parser.LimitedInfo{}, fmt.Errorf("price must be positive, got %d", price)
+	}
+
+	limited, ok := find(id)
+	if !ok {
+		return parser.LimitedInfo{}, fmt.Errorf("limited %s is not watched", id)
+	}
+
+	old := limited.Price
+	limited.Price = price
+	if err := limited.CheckResale(); err != nil {
+		return parser.LimitedInfo{}, err
+	}
+	if err := worker.Replace(limited); err != nil {
+		return parser.LimitedInfo{}, err
+	}
+
+	logger.Info("Target changed", "Limited ID", id, "Old Target", old, "Target", price)
+	return limited, nil
+}
+
+// Remove stops watching an item.
+func Remove(id string) error {
+	if !worker.Remove(id) {
+		return fmt.Errorf("limited %s is not watched", id)
+	}
+	return nil
+}
+
+// Pause holds the purchases of an item until Resume.
+func Pause(id string) error {
+	if !worker.Pause(id) {
+		return fmt.Errorf("limited %s is not watched or already paused", id)
+	}
+	return nil
+}
+
+// Resume undoes Pause.
+func Resume(id string) error {
+	if !worker.Resume(id) {
+		return fmt.Errorf("limited %s is not watched or not paused", id)
+	}
+	return nil
+}
+
+func find(id string) (parser.LimitedInfo, bool) {
+	for _, limited := range worker.Watched() {
+		if limited.Id == id {
+			return limited, true
+		}
+	}
+	return parser.LimitedInfo{}, false
+}
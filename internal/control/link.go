@@ -0,0 +1,108 @@
+package control
+
+import (
+	"html/template"
+	"net/http"
+	"sniper/internal/approval"
+)
+
+// The page behind a signed approval link. Opening the link only shows the request, link previews
+// of chat apps fetch it too, the decision is a separate POST.
+var approvalPage = template.Must(template.New("approval").Parse(`<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Purchase approval</title>
+  <style>
+    body { font-family: ui-monospace, Menlo, Consolas, monospace; background: #14161a; color: #d8dbe0; max-width: 32rem; margin: 2rem auto; padding: 0 1rem; }
+    button { font: inherit; padding: 0.5rem 1.5rem; margin-right: 1rem; border: 0; cursor: pointer; }
+    .approve { background: #3ba55d; color: white; }
+    .deny { background: #e03b3b; color: white; }
+  </style>
+</head>
+<body>
+{{if .Message}}
+  <p>{{.Message}}</p>
+{{else}}
+  <h1>Approve purchase?</h1>
+  <p><b>{{if .Request.ItemName}}{{.Request.ItemName}} ({{.Request.LimitedID}}){{else}}{{.Request.LimitedID}}{{end}}</b></p>
+  <p>Price: R$ {{.Request.Listing.Price}}<br>Target: R$ {{.Request.Target}}<br>Reason: {{.Request.Reason}}<br>
+     Expires: {{.Request.Expires.Format "15:04:05 MST"}}</p>
+  <form method="post">
+    <input type="hidden" name="expires" value="{{.Expires}}">
+    <input type="hidden" name="sig" value="{{.Sig}}">
+    <button class="approve" name="decision" value="approve">Approve</button>
+    <button class="deny" name="decision" value="deny">Deny</button>
+  </form>
+{{end}}
+</body>
+</html>
+`))
+
+type approvalView struct {
+	Request approval.Request
+	Expires string
+	Sig     string
+	Message string
+}
+
+// approvalLink shows the request of a signed approval link.
+func approvalLink(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	view := approvalView{Expires: r.URL.Query().Get("expires"), Sig: r.URL.Query().Get("sig")}
+
+	if err := approval.Verify(id, view.Expires, view.Sig); err != nil {
+		renderApproval(w, http.StatusForbidden, approvalView{Message: err.Error()})
+		return
+	}
+
+	found := false
+	for _, request := range approval.Pending() {
+		if request.ID == id {
+			view.Request, found = request, true
+		}
+	}
+	if !found {
+		renderApproval(w, http.StatusNotFound, approvalView{Message: "This purchase is no longer waiting for approval."})
+		return
+	}
+
+	renderApproval(w, http.StatusOK, view)
+}
+
+// decideLink applies the decision sent from the approval page.
+func decideLink(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := approval.Verify(id, r.FormValue("expires"), r.FormValue("sig")); err != nil {
+		renderApproval(w, http.StatusForbidden, approvalView{Message: err.Error()})
+		return
+	}
+
+	var err error
+	message := "Purchase approved, it goes through if the listing is still there."
+	switch r.FormValue("decision") {
+	case "approve":
+		err = approval.Approve(id)
+	case "deny":
+		err = approval.Deny(id)
+		message = "Purchase denied."
+	default:
+		renderApproval(w, http.StatusBadRequest, approvalView{Message: "Unknown decision."})
+		return
+	}
+	if err != nil {
+		renderApproval(w, http.StatusNotFound, approvalView{Message: err.Error()})
+		return
+	}
+
+	renderApproval(w, http.StatusOK, approvalView{Message: message})
+}
+
+func renderApproval(w http.ResponseWriter, status int, view approvalView) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := approvalPage.Execute(w, view); err != nil {
+		logger.Debug("Could not render the approval page", "Error", err)
+	}
+}
@@ -3,12 +3,14 @@ package csrf
 import (
 	"errors"
 	"net/http"
+	"sniper/internal/logging"
+	"sniper/internal/redact"
 	"sync"
 	"time"
-
-	"github.com/charmbracelet/log"
 )
 
+var logger = logging.For("csrf")
+
 // Global CSRF token and expiration details
 var (
 	Token         string
@@ -58,17 +60,25 @@ func UpdateCSRF(cookie string) error {
 	// Retrieve the CSRF token from the response header
 	newToken := resp.Header.Get("x-csrf-token")
 	if newToken == "" {
-		log.Warn("Failed to retreive CSRF token from Roblox API, might wanna re-check the Cookie.")
+		logger.Warn("Failed to retreive CSRF token from Roblox API, might wanna re-check the Cookie.")
 		return errors.New("failed to retrieve CSRF token")
 	}
 
 	// Update token and set the expiration
+	redact.Register(newToken)
 	Token = newToken
 	ExpiryDate = time.Now().Add(ValidDuration)
 
 	return nil
 }
 
+// Invalidate forces the next UpdateCSRF to fetch a new token, e.g. after the cookie changed.
+func Invalidate() {
+	mu.Lock()
+	defer mu.Unlock()
+	ExpiryDate = time.Time{}
+}
+
 // GetCSRF safely returns the current CSRF token, ensuring it's valid
 func GetCSRF(cookie string) (string, error) {
 	mu.RLock()
@@ -0,0 +1,111 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"sniper/internal/config"
+	"sniper/internal/control"
+	"sniper/internal/eventlog"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("dashboard")
+
+//go:embed static
+var static embed.FS
+
+// Point is a polled price of an item.
+type Point struct {
+	Time    time.Time `json:"time"`
+	Price   int       `json:"price"`
+	Target  int       `json:"target"`
+	Latency int64     `json:"latency_ms"`
+}
+
+var (
+	maxPoints int
+	history   = map[string][]Point{} // Limited ID -> latest polled prices, oldest first
+	historyMu sync.Mutex
+)
+
+// Start keeps the price history for the charts and registers the dashboard next to the
+// control API, it must run before control.Start and before the workers start.
+func Start(cfg config.ControlConfig) {
+	if !cfg.Enabled || !cfg.Dashboard {
+		return
+	}
+	maxPoints = cfg.HistoryPoints
+
+	events.Subscribe("dashboard", 0, func(event events.Event) {
+		if e, ok := event.(events.PriceObserved); ok {
+			record(e)
+		}
+		broadcast(event)
+	}, events.KindPriceObserved, events.KindWorkerStateChanged, events.KindPurchaseSucceeded, events.KindPurchaseFailed,
+		events.KindPriceAlert, events.KindApprovalDecided)
+
+	assets, _ := fs.Sub(static, "static")
+	control.Handle("GET /", http.FileServerFS(assets))
+	control.Handle("GET /api/stream", http.HandlerFunc(stream))
+	control.Handle("GET /api/purchases", http.HandlerFunc(purchases))
+	control.Handle("GET /api/prices/{id}", http.HandlerFunc(prices))
+
+	logger.Info("Web dashboard enabled", "Address", "http://"+cfg.Listen)
+}
+
+func record(e events.PriceObserved) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	points := append(history[e.LimitedID], Point{
+		Time:    e.Time,
+		Price:   e.Price,
+		Target:  e.Target,
+		Latency: e.Latency.Milliseconds(),
+	})
+	if len(points) > maxPoints {
+		points = points[len(points)-maxPoints:]
+	}
+	history[e.LimitedID] = points
+}
+
+// prices answers with the price history of an item for its chart.
+func prices(w http.ResponseWriter, r *http.Request) {
+	historyMu.Lock()
+	points := append([]Point{}, history[r.PathValue("id")]...)
+	historyMu.Unlock()
+
+	control.WriteJSON(w, http.StatusOK, points)
+}
+
+// purchases answers with the purchases and failed purchases of the ledger, newest first.
+// since is a duration like 24h, it defaults to a week.
+func purchases(w http.ResponseWriter, r *http.Request) {
+	window := 7 * 24 * time.Hour
+	if since := r.URL.Query().Get("since"); since != "" {
+		parsed, err := time.ParseDuration(since)
+		if err != nil {
+			control.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		window = parsed
+	}
+
+	logged, err := eventlog.ReadSince(time.Now().Add(-window))
+	if err != nil {
+		control.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	found := []eventlog.Event{}
+	for i := len(logged) - 1; i >= 0; i-- {
+		if logged[i].Type == eventlog.TypePurchase || logged[i].Type == eventlog.TypePurchaseFailure {
+			found = append(found, logged[i])
+		}
+	}
+	control.WriteJSON(w, http.StatusOK, found)
+}
@@ -0,0 +1,278 @@
+// Dashboard of the sniper, everything goes through the control API on the same address.
+"use strict";
+
+const items = new Map(); // Limited ID -> row state
+let selected = null;
+let points = [];
+
+async function api(method, path, body) {
+  const options = { method, headers: {} };
+  if (body !== undefined) {
+    // The API only accepts changes sent as JSON
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch(path, options);
+  if (!response.ok) {
+    let message = response.statusText;
+    try {
+      message = (await response.json()).error || message;
+    } catch (_) {}
+    throw new Error(message);
+  }
+  return response.status === 204 ? null : response.json();
+}
+
+function row(id) {
+  if (!items.has(id)) {
+    items.set(id, { id, name: "", target: 0, price: null, latency: null, state: "starting", reason: "", paused: false, watched: true });
+  }
+  return items.get(id);
+}
+
+async function loadWatchlist() {
+  const watchlist = await api("GET", "/api/watchlist");
+  const watched = new Set();
+  for (const limited of watchlist) {
+    const item = row(limited.id);
+    item.target = limited.price;
+    item.source = limited.source || "";
+    item.paused = limited.paused;
+    item.watched = true;
+    watched.add(limited.id);
+  }
+  for (const item of items.values()) {
+    item.watched = watched.has(item.id);
+  }
+  render();
+}
+
+async function loadPurchases() {
+  const purchases = await api("GET", "/api/purchases");
+  const body = document.querySelector("#purchases tbody");
+  body.replaceChildren();
+  for (const p of purchases.slice(0, 100)) {
+    const tr = document.createElement("tr");
+    const result = p.type === "purchase" ? "bought" : "failed: " + (p.message || "");
+    cells(tr, [new Date(p.time).toLocaleString(), items.get(p.limited_id)?.name || p.limited_id, p.price, p.target || "", result]);
+    tr.lastChild.className = p.type === "purchase" ? "good" : "bad";
+    body.append(tr);
+  }
+}
+
+async function loadApprovals() {
+  const approvals = await api("GET", "/api/approvals");
+  const body = document.querySelector("#approvals tbody");
+  body.replaceChildren();
+  for (const request of approvals) {
+    const tr = document.createElement("tr");
+    const actions = document.createElement("span");
+    const decide = (decision) => () => api("POST", `/api/approvals/${encodeURIComponent(request.id)}/${decision}`, {}).then(loadApprovals);
+    actions.append(button("Approve", decide("approve")), " ", button("Deny", decide("deny")));
+    cells(tr, [
+      request.item_name ? `${request.item_name} (${request.limited_id})` : request.limited_id,
+      request.listing.price,
+      request.target,
+      request.reason,
+      new Date(request.expires).toLocaleTimeString(),
+      actions,
+    ]);
+    body.append(tr);
+  }
+  document.getElementById("approvals-section").hidden = approvals.length === 0;
+}
+
+async function loadChart(id) {
+  selected = id;
+  points = await api("GET", "/api/prices/" + encodeURIComponent(id));
+  render();
+}
+
+function cells(tr, values) {
+  for (const value of values) {
+    const td = document.createElement("td");
+    if (value instanceof Node) {
+      td.append(value);
+    } else {
+      td.textContent = value ?? "";
+    }
+    tr.append(td);
+  }
+}
+
+function button(label, action) {
+  const b = document.createElement("button");
+  b.type = "button";
+  b.textContent = label;
+  b.addEventListener("click", (event) => {
+    event.stopPropagation();
+    action().then(loadWatchlist).catch((err) => alert(err.message));
+  });
+  return b;
+}
+
+function targetForm(item) {
+  const form = document.createElement("form");
+  const input = document.createElement("input");
+  input.name = "price";
+  input.type = "number";
+  input.min = "1";
+  input.value = item.target;
+  form.append(input);
+  form.addEventListener("click", (event) => event.stopPropagation());
+  form.addEventListener("submit", (event) => {
+    event.preventDefault();
+    api("PUT", "/api/watchlist/" + encodeURIComponent(item.id), { price: Number(input.value) })
+      .then(loadWatchlist)
+      .catch((err) => alert(err.message));
+  });
+  return form;
+}
+
+function render() {
+  const body = document.querySelector("#watchlist tbody");
+  // Keep the row being edited, re-rendering would throw away what was typed
+  if (body.contains(document.activeElement) && document.activeElement.name === "price") {
+    renderChart();
+    return;
+  }
+
+  body.replaceChildren();
+  for (const item of items.values()) {
+    const tr = document.createElement("tr");
+    const state = item.paused ? "paused" : item.watched ? item.state : "stopped";
+    tr.className = [state, item.id === selected ? "selected" : "", item.price !== null && item.price <= item.target ? "hit" : ""].join(" ");
+
+    let distance = "";
+    if (item.price !== null && item.target > 0) {
+      const diff = item.price - item.target;
+      distance = `${diff > 0 ? "+" : ""}${diff} (${Math.round((diff / item.target) * 100)}%)`;
+    }
+
+    let name = item.name ? `${item.name} (${item.id})` : item.id;
+    if (item.source) {
+      name = item.source + ": " + name;
+    }
+
+    const actions = document.createElement("span");
+    if (item.watched) {
+      actions.append(
+        item.paused
+          ? button("Resume", () => api("POST", `/api/watchlist/${encodeURIComponent(item.id)}/resume`, {}))
+          : button("Pause", () => api("POST", `/api/watchlist/${encodeURIComponent(item.id)}/pause`, {})),
+        " ",
+        button("Remove", () => api("DELETE", "/api/watchlist/" + encodeURIComponent(item.id), {})),
+      );
+    }
+
+    cells(tr, [
+      name,
+      item.watched ? targetForm(item) : item.target,
+      item.price ?? "-",
+      distance || "-",
+      item.latency !== null ? item.latency + "ms" : "-",
+      item.reason ? `${state}: ${item.reason}` : state,
+      actions,
+    ]);
+    tr.addEventListener("click", () => loadChart(item.id));
+    body.append(tr);
+  }
+
+  renderChart();
+}
+
+function renderChart() {
+  const section = document.getElementById("chart-section");
+  if (selected === null) {
+    section.hidden = true;
+    return;
+  }
+  section.hidden = false;
+
+  const item = items.get(selected);
+  document.getElementById("chart-title").textContent = item?.name || selected;
+
+  const svg = document.getElementById("chart");
+  svg.replaceChildren();
+  if (points.length === 0) {
+    document.getElementById("chart-range").textContent = "no prices yet";
+    return;
+  }
+
+  const prices = points.flatMap((p) => [p.price, p.target]);
+  const low = Math.min(...prices);
+  const high = Math.max(...prices);
+  const span = high - low || 1;
+  const x = (i) => (points.length === 1 ? 400 : (i / (points.length - 1)) * 800);
+  const y = (price) => 190 - ((price - low) / span) * 180;
+
+  const ns = "http://www.w3.org/2000/svg";
+  const line = document.createElementNS(ns, "polyline");
+  line.setAttribute("class", "price");
+  line.setAttribute("points", points.map((p, i) => `${x(i)},${y(p.price)}`).join(" "));
+
+  const target = document.createElementNS(ns, "polyline");
+  target.setAttribute("class", "target");
+  target.setAttribute("points", points.map((p, i) => `${x(i)},${y(p.target)}`).join(" "));
+
+  svg.append(target, line);
+  document.getElementById("chart-range").textContent =
+    `R$ ${low} – ${high}, ${points.length} polls since ${new Date(points[0].time).toLocaleTimeString()}`;
+}
+
+function listen() {
+  const status = document.getElementById("status");
+  const source = new EventSource("/api/stream");
+  source.onopen = () => (status.textContent = "live");
+  source.onerror = () => (status.textContent = "reconnecting…");
+
+  source.addEventListener("price_observed", (message) => {
+    const e = JSON.parse(message.data);
+    const item = row(e.limited_id);
+    item.price = e.price;
+    item.target = e.target;
+    item.latency = Math.round(e.latency / 1e6);
+    if (e.limited_id === selected) {
+      points.push({ time: e.time, price: e.price, target: e.target });
+    }
+    render();
+  });
+
+  source.addEventListener("worker_state_changed", (message) => {
+    const e = JSON.parse(message.data);
+    const item = row(e.limited_id);
+    item.state = e.state;
+    item.reason = e.reason || "";
+    if (e.item_name) {
+      item.name = e.item_name;
+    }
+    render();
+  });
+
+  for (const kind of ["purchase_succeeded", "purchase_failed"]) {
+    source.addEventListener(kind, () => loadPurchases().catch(() => {}));
+  }
+  for (const kind of ["price_alert", "approval_decided"]) {
+    source.addEventListener(kind, () => loadApprovals().catch(() => {}));
+  }
+}
+
+document.getElementById("add").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const input = event.target.elements.line;
+  const error = document.getElementById("add-error");
+  api("POST", "/api/watchlist", { line: input.value })
+    .then(() => {
+      input.value = "";
+      error.textContent = "";
+      return loadWatchlist();
+    })
+    .catch((err) => (error.textContent = err.message));
+});
+
+listen();
+loadWatchlist();
+loadPurchases();
+loadApprovals();
+// Items added or removed elsewhere (discovery, the TUI, the API) show up without a reload
+setInterval(() => loadWatchlist().catch(() => {}), 10000);
@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"sniper/internal/events"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// message is a server-sent event, Name is the kind of the bus event the page listens for.
+type message struct {
+	Name string
+	Data []byte
+}
+
+var (
+	clients   = map[chan message]struct{}{}
+	clientsMu sync.Mutex
+)
+
+// broadcast hands an event to every open page, a page that can't keep up loses it.
+func broadcast(event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Debug("Could not encode event", "Kind", event.Kind(), "Error", err)
+		return
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	for client := range clients {
+		select {
+		case client <- message{Name: string(event.Kind()), Data: data}:
+		default:
+		}
+	}
+}
+
+// stream sends the events of the bus to a page until it is closed.
+func stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan message, 64)
+	clientsMu.Lock()
+	clients[client] = struct{}{}
+	clientsMu.Unlock()
+
+	defer func() {
+		clientsMu.Lock()
+		delete(clients, client)
+		clientsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Proxies and browsers drop a connection that stays silent for too long
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case msg := <-client:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Name, msg.Data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"sniper/internal/config"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/rules"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sniper/internal/worker"
+	"strconv"
+	"time"
+)
+
+var logger = logging.For("discovery")
+
+const (
+	ActionAlert = "alert"
+	ActionWatch = "watch"
+)
+
+var (
+	quietUntil = map[string]time.Time{} // Limited ID -> time it may be reported again
+	watching   = map[string]time.Time{} // Limited ID -> time its worker is removed
+)
+
+// Start searches the catalog every interval for collectibles listed far below their RAP.
+func Start(cfg config.DiscoveryConfig) {
+	if !cfg.Enabled {
+		return
+	}
+
+	logger.Info("Catalog discovery enabled", "Action", cfg.Action, "Min Discount", cfg.MinDiscount, "Interval", time.Duration(cfg.Interval)*time.Second)
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			expire()
+			search(cfg)
+			<-ticker.C
+		}
+	}()
+}
+
+// expire removes discovered items whose watch period is over.
+func expire() {
+	now := time.Now()
+	for id, until := range watching {
+		if now.After(until) {
+			worker.Remove(id)
+			delete(watching, id)
+		}
+	}
+}
+
+func search(cfg config.DiscoveryConfig) {
+	if !session.Active() {
+		return
+	}
+
+	cookie := session.Cookie()
+	results, err := scraper.SearchCatalog(cookie, scraper.SearchFilter{
+		Category:    cfg.Category,
+		Subcategory: cfg.Subcategory,
+		Creator:     cfg.Creator,
+		Keyword:     cfg.Keyword,
+		MinPrice:    cfg.MinPrice,
+		MaxPrice:    cfg.MaxPrice,
+	}, cfg.Pages)
+	if err != nil {
+		logger.Error("Catalog search failed", "Error", err)
+		if len(results) == 0 {
+			return
+		}
+	}
+
+	watched := map[string]bool{}
+	for _, limited := range worker.Watched() {
+		watched[limited.Id] = true
+	}
+
+	now := time.Now()
+	cooldown := time.Duration(cfg.WatchFor) * time.Minute
+	for _, result := range results {
+		id := strconv.Itoa(result.Id)
+		if result.ItemType != "Asset" || result.LowestPrice <= 0 || watched[id] || now.Before(quietUntil[id]) {
+			continue
+		}
+
+		resale, err := scraper.FetchResaleData(cookie, id)
+		if err != nil {
+			logger.Debug("Could not fetch resale data", "Limited ID", id, "Error", err)
+			continue
+		}
+
+		discount := rules.Discount(result.LowestPrice, resale.RecentAveragePrice)
+		if resale.RecentAveragePrice <= 0 || discount < cfg.MinDiscount {
+			continue
+		}
+		quietUntil[id] = now.Add(cooldown)
+
+		found := events.ItemDiscovered{
+			Header:   events.Stamp("", id),
+			ItemName: result.Name,
+			Creator:  result.CreatorName,
+			Price:    result.LowestPrice,
+			RAP:      resale.RecentAveragePrice,
+			Discount: discount,
+		}
+
+		if cfg.Action == ActionWatch {
+			watch(cfg, result, &found)
+		}
+
+		events.Publish(found)
+	}
+}
+
+// watch adds a discovered item to the watchlist until its watch period is over.
+func watch(cfg config.DiscoveryConfig, result scraper.SearchResult, found *events.ItemDiscovered) {
+	if len(watching) >= cfg.MaxWatched {
+		logger.Debug("Already watching the most discovered items, alerting only.", "Limited ID", found.LimitedID)
+		return
+	}
+
+	target := rules.DiscoveryTarget(found.RAP, cfg.TargetPercent)
+	if target <= 0 {
+		return
+	}
+
+	limited := parser.LimitedInfo{
+		Id:       found.LimitedID,
+		Price:    target,
+		Kind:     parser.KindClassic,
+		Type:     parser.TypeAsset,
+		MaxOwned: cfg.MaxOwned,
+		Source:   parser.SourceDiscovery,
+		Action:   parser.ActionBuy,
+	}
+	if result.CollectibleItemID != "" {
+		limited.Kind = parser.KindUGC
+	}
+
+	if err := worker.Add(limited); err != nil {
+		logger.Warn("Could not watch discovered item", "Limited ID", limited.Id, "Error", err)
+		return
+	}
+
+	until := time.Now().Add(time.Duration(cfg.WatchFor) * time.Minute)
+	watching[limited.Id] = until
+
+	found.Watched = true
+	found.Target = target
+	found.Until = until
+}
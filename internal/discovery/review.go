@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"fmt"
+	"sniper/internal/eventlog"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Reviewed is a discovered item with what came of it.
+type Reviewed struct {
+	Time      time.Time `json:"time"`
+	LimitedID string    `json:"limited_id"`
+	Price     int       `json:"price"`
+	RAP       int       `json:"rap"`
+	Target    int       `json:"target,omitempty"` // 0 when only alerted
+	Note      string    `json:"note"`
+	Bought    int       `json:"bought"` // Copies bought after the discovery
+	Spent     int       `json:"spent"`
+}
+
+// Review pairs the discoveries of an event log with the purchases of the same items that followed.
+func Review(logged []eventlog.Event) []Reviewed {
+	var reviewed []Reviewed
+	latest := map[string]int{} // Limited ID -> index of its latest discovery
+
+	for _, event := range logged {
+		switch event.Type {
+		case eventlog.TypeDiscovery:
+			latest[event.LimitedID] = len(reviewed)
+			reviewed = append(reviewed, Reviewed{
+				Time:      event.Time,
+				LimitedID: event.LimitedID,
+				Price:     event.Price,
+				RAP:       event.RAP,
+				Target:    event.Target,
+				Note:      event.Message,
+			})
+		case eventlog.TypePurchase:
+			if i, ok := latest[event.LimitedID]; ok {
+				reviewed[i].Bought++
+				reviewed[i].Spent += event.Price
+			}
+		}
+	}
+
+	return reviewed
+}
+
+// Text renders reviewed discoveries as a table.
+func Text(reviewed []Reviewed) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tITEM\tPRICE\tRAP\tTARGET\tBOUGHT\tSPENT\tNOTE")
+	for _, r := range reviewed {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n", r.Time.Local().Format(time.RFC3339), r.LimitedID, r.Price, r.RAP, r.Target, r.Bought, r.Spent, r.Note)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"sniper/internal/config"
+	"sniper/internal/csrf"
+	"sniper/internal/parser"
+	"sniper/internal/redact"
+	"sniper/internal/scraper"
+	"sniper/internal/secrets"
+	"sniper/internal/webhook"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// report keeps track of the checks that ran, so the command can exit non-zero.
+type report struct {
+	failures int
+}
+
+func (r *report) pass(check string, keyvals ...interface{}) {
+	log.Info("✅ "+check, keyvals...)
+}
+
+func (r *report) fail(check string, err error, keyvals ...interface{}) {
+	r.failures++
+	log.Error("❌ "+check, append(keyvals, "Error", err)...)
+}
+
+func (r *report) skip(check, reason string) {
+	log.Warn("⏭️  "+check, "Reason", reason)
+}
+
+// Run goes through every startup requirement and returns the number of failed checks.
+// Checks that depend on an earlier one (e.g. the balance needs a valid cookie) are skipped when it failed.
+func Run(configPath, filePath string) int {
+	r := &report{}
+
+	// Configuration
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		r.fail("Config file", err, "Path", configPath)
+		return r.failures
+	}
+	r.pass("Config file", "Path", configPath)
+
+	if err := secrets.Apply(cfg); err != nil {
+		r.fail("Credentials", err)
+	} else {
+		r.pass("Credentials")
+	}
+	redact.Register(config.Secrets(cfg)...)
+
+	if err := config.Validate(cfg); err != nil {
+		if problems, ok := err.(config.ValidationError); ok {
+			for _, problem := range problems {
+				r.fail("Config", errors.New(problem.Message), "Field", problem.Path)
+			}
+		} else {
+			r.fail("Config", err)
+		}
+	} else {
+		r.pass("Config")
+	}
+
+	// Limiteds file
+	var limiteds []parser.LimitedInfo
+	if filePath == "" {
+		r.skip("Limiteds file", "no --file given")
+	} else {
+		infos, bad, err := parser.ParseFile(filePath)
+		switch {
+		case err != nil:
+			r.fail("Limiteds file", err, "Path", filePath)
+		case len(bad) > 0:
+			for _, line := range bad {
+				r.fail("Limiteds file", line, "Path", filePath)
+			}
+		case len(infos) == 0:
+			r.fail("Limiteds file", fmt.Errorf("no limiteds found"), "Path", filePath)
+		default:
+			r.pass("Limiteds file", "Path", filePath, "Limiteds", len(infos))
+		}
+		limiteds = infos
+	}
+
+	// Product IDs
+	for _, limited := range limiteds {
+		// New drops are off sale until their release, only their catalog entry can be checked
+		if limited.IsDrop() {
+			drop, on_sale, err := scraper.FetchDrop(cfg.Cookie, limited)
+			if err != nil {
+				r.fail("Drop", err, "Limited ID", limited.Id)
+				continue
+			}
+			r.pass("Drop", "Limited ID", limited.Id, "Release", limited.Release.Local().Format(time.RFC1123), "On Sale", on_sale, "Price", drop.Price)
+			continue
+		}
+
+		info, err := scraper.FetchListing(cfg.Cookie, limited)
+		if err != nil {
+			r.fail("Product ID", err, "Limited ID", limited.Id, "Kind", limited.Kind)
+			continue
+		}
+		if limited.Kind == parser.KindUGC {
+			r.pass("Product ID", "Limited ID", limited.Id, "Kind", limited.Kind, "Type", limited.Type, "Collectible Item ID", info.CollectibleItemID, "Lowest Price", info.Price)
+			continue
+		}
+		if info.ProductID <= 0 {
+			r.fail("Product ID", fmt.Errorf("item page has no product id, is it a limited that is on sale? UGC limiteds need kind=ugc"), "Limited ID", limited.Id)
+			continue
+		}
+		r.pass("Product ID", "Limited ID", limited.Id, "Kind", limited.Kind, "Product ID", info.ProductID, "Lowest Price", info.Price)
+	}
+
+	// Authentication
+	user, err := scraper.FetchAuthenticated(cfg.Cookie)
+	if err == nil && user.Id <= 0 {
+		err = fmt.Errorf("roblox did not recognise the cookie")
+	}
+	if err != nil {
+		r.fail("Cookie", err)
+	} else {
+		r.pass("Cookie", "Username", user.Username, "Id", user.Id)
+	}
+
+	if user.Id <= 0 {
+		r.skip("Robux balance", "not authenticated")
+	} else if balance, err := scraper.FetchBalance(cfg.Cookie, user.Id); err != nil {
+		r.fail("Robux balance", err)
+	} else {
+		r.pass("Robux balance", "Robux", balance)
+	}
+
+	if user.Id <= 0 {
+		r.skip("Inventory", "not authenticated")
+	} else if owned, err := scraper.FetchCollectibles(cfg.Cookie, user.Id); err != nil {
+		r.fail("Inventory", err)
+	} else {
+		r.pass("Inventory", "Collectibles", len(owned))
+	}
+
+	// CSRF
+	csrf.Init(5 * time.Second)
+	if err := csrf.UpdateCSRF(cfg.Cookie); err != nil {
+		r.fail("CSRF token", err)
+	} else {
+		r.pass("CSRF token")
+	}
+
+	// Webhooks
+	errs := webhook.Check(cfg, webhook.Message{
+		Event:       webhook.EventStartup,
+		Title:       "Sniper Doctor",
+		Description: "Test notification, this sink is configured correctly.",
+		Color:       0x42b3f5,
+	})
+	for _, err := range errs {
+		r.fail("Webhook", err)
+	}
+	if len(errs) == 0 {
+		r.pass("Webhook", "Message", "test notification sent")
+	}
+
+	return r.failures
+}
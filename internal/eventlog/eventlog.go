@@ -0,0 +1,159 @@
+package eventlog
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sniper/internal/logging"
+	"sniper/internal/redact"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var logger = logging.For("eventlog")
+
+const DefaultPath = "events.jsonl"
+
+// Event types written to the log.
+const (
+	TypePurchase        = "purchase"
+	TypePurchaseFailure = "purchase_failure"
+	TypeError           = "error"
+	TypeNearMiss        = "near_miss"
+	TypeResale          = "resale"
+	TypeResaleFailure   = "resale_failure"
+	TypeSale            = "sale"
+	TypeReprice         = "reprice"
+	TypeDiscovery       = "discovery"
+	TypeAlert           = "alert"
+	TypeApproval        = "approval"
+)
+
+// Error types, used to group error rates in the digest.
+const (
+	ErrorScrape    = "scrape"
+	ErrorPurchase  = "purchase"
+	ErrorThumbnail = "thumbnail"
+	ErrorResale    = "resale_data"
+)
+
+// Event is a single line of the event log. Polls are too frequent to log one by one,
+// they are counted in memory instead.
+type Event struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	LimitedID   string    `json:"limited_id"`
+	Attempt     string    `json:"attempt,omitempty"` // Correlation id of the poll that produced the event
+	Price       int       `json:"price,omitempty"`
+	Target      int       `json:"target,omitempty"`
+	RAP         int       `json:"rap,omitempty"`
+	Paid        int       `json:"paid,omitempty"`     // Price paid for a copy that is resold
+	Proceeds    int       `json:"proceeds,omitempty"` // What a sale paid out after the marketplace fee
+	Balance     int       `json:"balance,omitempty"`
+	SellerID    int       `json:"seller_id,omitempty"`
+	UserAssetID int       `json:"user_asset_id,omitempty"`
+	ErrorType   string    `json:"error_type,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+var (
+	path = DefaultPath
+	file *os.File
+	mu   sync.Mutex
+)
+
+// Init opens (or creates) the append-only event log.
+func Init(logPath string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logPath == "" {
+		logPath = DefaultPath
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create event log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+
+	if file != nil {
+		file.Close()
+	}
+	file = f
+	path = logPath
+
+	return nil
+}
+
+// Record appends an event to the log, it is a no-op until Init has been called.
+func Record(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Message = redact.String(event.Message)
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("Failed to marshal event", "Error", err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logger.Error("Failed to write event log", "Error", err)
+	}
+}
+
+// ReadSince returns every logged event at or after the given time.
+func ReadSince(since time.Time) ([]Event, error) {
+	mu.Lock()
+	logPath := path
+	mu.Unlock()
+
+	return ReadFile(logPath, since)
+}
+
+// ReadFile reads the events at or after since from an event log that is not the open one,
+// e.g. for commands that run next to the sniper.
+func ReadFile(logPath string, since time.Time) ([]Event, error) {
+	f, err := os.Open(logPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A crash can leave a torn last line behind, skip it
+			continue
+		}
+		if !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading event log: %w", err)
+	}
+
+	return events, nil
+}
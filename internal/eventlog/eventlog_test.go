@@ -0,0 +1,43 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadSince(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := Init(logPath); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	Record(Event{Time: start.Add(-time.Second), Type: TypeError, LimitedID: "1111"})
+	Record(Event{Time: start, Type: TypeNearMiss, LimitedID: "2222"})
+	Record(Event{Time: start.Add(time.Minute), Type: TypePurchase, LimitedID: "3333"})
+
+	// A crash in the middle of a write leaves a torn last line
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-10-19T12:05:00Z","type":"purch`)
+	f.Close()
+
+	events, err := ReadSince(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].LimitedID != "2222" || events[1].LimitedID != "3333" {
+		t.Errorf("got %+v, want the events at and after the start without the torn line", events)
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	events, err := ReadFile(filepath.Join(t.TempDir(), "missing.jsonl"), time.Time{})
+	if err != nil || events != nil {
+		t.Errorf("got %v, %v, want no events and no error for a log that does not exist yet", events, err)
+	}
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"sniper/internal/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var logger = logging.For("events")
+
+const DefaultBuffer = 256
+
+// lossy kinds are published on every poll, a subscriber that can't keep up may lose them.
+// Every other kind is always delivered, however far behind the subscriber is.
+var lossy = map[Kind]bool{
+	KindPriceObserved: true,
+}
+
+// subscriber owns a queue drained by its own goroutine. Once buffer events are waiting, new
+// lossy events are dropped instead of growing the queue, unless the subscriber is lossless.
+type subscriber struct {
+	name     string
+	kinds    map[Kind]bool // nil accepts every kind
+	buffer   int
+	lossless bool
+	queue    []Event
+	queueMu  sync.Mutex
+	wake     chan struct{}
+	dropped  atomic.Int64
+	lastWarn atomic.Int64 // Unix seconds of the last drop warning
+}
+
+var (
+	subscribers []*subscriber
+	mu          sync.RWMutex
+)
+
+// Subscribe runs handle in its own goroutine for every published event of the given kinds,
+// or of every kind when none are given. A buffer of 0 uses DefaultBuffer.
+func Subscribe(name string, buffer int, handle func(Event), kinds ...Kind) {
+	subscribe(&subscriber{name: name, buffer: buffer}, handle, kinds)
+}
+
+// SubscribeLossless is Subscribe for a subscriber that must see every event, lossy kinds
+// included. Its queue grows for as long as it falls behind.
+func SubscribeLossless(name string, handle func(Event), kinds ...Kind) {
+	subscribe(&subscriber{name: name, lossless: true}, handle, kinds)
+}
+
+func subscribe(s *subscriber, handle func(Event), kinds []Kind) {
+	if s.buffer <= 0 {
+		s.buffer = DefaultBuffer
+	}
+	s.wake = make(chan struct{}, 1)
+	if len(kinds) > 0 {
+		s.kinds = map[Kind]bool{}
+		for _, kind := range kinds {
+			s.kinds[kind] = true
+		}
+	}
+
+	mu.Lock()
+	subscribers = append(subscribers, s)
+	mu.Unlock()
+
+	go func() {
+		for range s.wake {
+			for _, event := range s.take() {
+				handle(event)
+			}
+		}
+	}()
+}
+
+// Publish hands the event to every subscriber without ever blocking, a purchase is never slowed
+// down by a subscriber. Only lossy events are dropped when a subscriber falls behind.
+func Publish(event Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, s := range subscribers {
+		if s.kinds != nil && !s.kinds[event.Kind()] {
+			continue
+		}
+		s.push(event)
+	}
+}
+
+func (s *subscriber) push(event Event) {
+	s.queueMu.Lock()
+	if len(s.queue) >= s.buffer && lossy[event.Kind()] && !s.lossless {
+		s.queueMu.Unlock()
+		s.drop(event)
+		return
+	}
+	s.queue = append(s.queue, event)
+	backlog := len(s.queue)
+	s.queueMu.Unlock()
+
+	if s.lossless && backlog == s.buffer {
+		logger.Warn("Subscriber is falling behind, queueing events.", "Subscriber", s.name, "Queued", backlog)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take empties the queue, events come out in the order they were published.
+func (s *subscriber) take() []Event {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	events := s.queue
+	s.queue = nil
+	return events
+}
+
+func (s *subscriber) drop(event Event) {
+	total := s.dropped.Add(1)
+
+	// At most one warning per second and subscriber, a stuck subscriber would flood the log otherwise
+	now := time.Now().Unix()
+	if last := s.lastWarn.Load(); now > last && s.lastWarn.CompareAndSwap(last, now) {
+		logger.Warn("Subscriber is falling behind, dropping events.", "Subscriber", s.name, "Event", event.Kind(), "Dropped", total)
+	}
+}
+
+// Dropped returns how many lossy events each subscriber lost since startup.
+func Dropped() map[string]int64 {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	counts := make(map[string]int64, len(subscribers))
+	for _, s := range subscribers {
+		counts[s.name] += s.dropped.Load()
+	}
+	return counts
+}
+
+// Stamp fills in the time of a header, publishers call it when building an event.
+func Stamp(attempt, limitedID string) Header {
+	return Header{Time: time.Now(), Attempt: attempt, LimitedID: limitedID}
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// stalled is a subscriber that blocks until release is closed and counts what it receives.
+type stalled struct {
+	release  chan struct{}
+	mu       sync.Mutex
+	observed int
+	failed   int
+}
+
+func (s *stalled) handle(event Event) {
+	<-s.release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch event.(type) {
+	case PriceObserved:
+		s.observed++
+	case PurchaseFailed:
+		s.failed++
+	}
+}
+
+// waitFor returns the counts once the subscriber got want purchase events, or after a timeout.
+func (s *stalled) waitFor(want int) (observed, failed int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		observed, failed = s.observed, s.failed
+		s.mu.Unlock()
+		if failed >= want || time.Now().After(deadline) {
+			return observed, failed
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStalledSubscribers(t *testing.T) {
+	const published = 50
+
+	lossy := &stalled{release: make(chan struct{})}
+	lossless := &stalled{release: make(chan struct{})}
+	Subscribe("test-lossy", 1, lossy.handle, KindPriceObserved, KindPurchaseFailed)
+	SubscribeLossless("test-lossless", lossless.handle, KindPriceObserved, KindPurchaseFailed)
+
+	for i := 0; i < published; i++ {
+		Publish(PriceObserved{Header: Stamp("", "1234")})
+		Publish(PurchaseFailed{Header: Stamp("", "1234")})
+	}
+	close(lossy.release)
+	close(lossless.release)
+
+	if observed, failed := lossless.waitFor(published); observed != published || failed != published {
+		t.Errorf("lossless subscriber got %d observations and %d failures, want %d of each", observed, failed, published)
+	}
+
+	observed, failed := lossy.waitFor(published)
+	if failed != published {
+		t.Errorf("purchase events must never be dropped, got %d of %d", failed, published)
+	}
+	if observed >= published {
+		t.Errorf("a stalled subscriber with a buffer of 1 kept all %d price observations", observed)
+	}
+	if dropped := Dropped()["test-lossy"]; dropped == 0 {
+		t.Error("dropped price observations were not counted")
+	}
+}
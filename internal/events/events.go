@@ -0,0 +1,218 @@
+package events
+
+import (
+	"time"
+)
+
+// Kind names an event type, subscribers switch on the concrete type instead.
+type Kind string
+
+const (
+	KindPriceObserved      Kind = "price_observed"
+	KindScrapeFailed       Kind = "scrape_failed"
+	KindTargetHit          Kind = "target_hit"
+	KindPurchaseAttempted  Kind = "purchase_attempted"
+	KindPurchaseSucceeded  Kind = "purchase_succeeded"
+	KindPurchaseFailed     Kind = "purchase_failed"
+	KindWorkerStateChanged Kind = "worker_state_changed"
+	KindResaleListed       Kind = "resale_listed"
+	KindResaleFailed       Kind = "resale_failed"
+	KindListingSold        Kind = "listing_sold"
+	KindListingRepriced    Kind = "listing_repriced"
+	KindItemDiscovered     Kind = "item_discovered"
+	KindPriceAlert         Kind = "price_alert"
+	KindApprovalDecided    Kind = "approval_decided"
+)
+
+// Event is anything published on the bus.
+type Event interface {
+	Kind() Kind
+	Meta() Header
+}
+
+// Header is shared by every event. Attempt ties together the events of one poll-to-purchase attempt.
+type Header struct {
+	Time      time.Time `json:"time"`
+	Attempt   string    `json:"attempt,omitempty"`
+	LimitedID string    `json:"limited_id"`
+}
+
+func (h Header) Meta() Header { return h }
+
+// Listing is what a poll saw for an item.
+type Listing struct {
+	ProductID   int `json:"product_id"`
+	Price       int `json:"price"`
+	SellerID    int `json:"seller_id"`
+	UserAssetID int `json:"user_asset_id"`
+
+	// Set instead of ProductID and UserAssetID for UGC limiteds
+	CollectibleItemID     string `json:"collectible_item_id,omitempty"`
+	CollectibleProductID  string `json:"collectible_product_id,omitempty"`
+	CollectibleInstanceID string `json:"collectible_instance_id,omitempty"`
+	SerialNumber          int    `json:"serial_number,omitempty"`
+	SellerType            string `json:"seller_type,omitempty"` // Group when a drop is sold by a group, users otherwise
+}
+
+// PriceObserved is published after every successful poll.
+type PriceObserved struct {
+	Header
+	Listing
+	Target  int           `json:"target"`
+	Latency time.Duration `json:"latency"`
+}
+
+// ScrapeFailed is published when a poll could not read the item.
+type ScrapeFailed struct {
+	Header
+	Err error `json:"-"`
+}
+
+// TargetHit is published when a listing is at or below the target, right before buying it.
+type TargetHit struct {
+	Header
+	Listing
+	Target int `json:"target"`
+}
+
+// PurchaseAttempted is published when the purchase request is about to be sent.
+type PurchaseAttempted struct {
+	Header
+	Listing
+	Target int `json:"target"`
+}
+
+// PurchaseSucceeded is published once Roblox confirmed the purchase.
+type PurchaseSucceeded struct {
+	Header
+	Listing
+	ItemName            string        `json:"item_name"`
+	ItemType            string        `json:"item_type"` // parser.TypeAsset or parser.TypeBundle
+	Target              int           `json:"target"`
+	Balance             int           `json:"balance"` // Robux left after the sale
+	Latency             time.Duration `json:"latency"` // Purchase request round trip
+	DetectionToPurchase time.Duration `json:"detection_to_purchase"`
+}
+
+// PurchaseFailed is published when Roblox refused the purchase (Rejected) or the request itself failed.
+type PurchaseFailed struct {
+	Header
+	Listing
+	ItemName  string `json:"item_name"`
+	ItemType  string `json:"item_type"`
+	Target    int    `json:"target"`
+	Rejected  bool   `json:"rejected"`
+	Message   string `json:"message"`
+	Shortfall int    `json:"shortfall,omitempty"`
+	Balance   int    `json:"balance"`
+}
+
+// Worker states.
+const (
+	StateActive  = "active"
+	StatePaused  = "paused"
+	StateStopped = "stopped"
+)
+
+// WorkerStateChanged is published when a worker starts, pauses, resumes or stops.
+type WorkerStateChanged struct {
+	Header
+	ItemName string `json:"item_name,omitempty"`
+	Source   string `json:"source,omitempty"` // parser.SourceDiscovery for discovered items
+	State    string `json:"state"`
+	Reason   string `json:"reason,omitempty"`
+	Target   int    `json:"target,omitempty"`
+}
+
+// ResaleListed is published once a sniped copy was put back on sale.
+type ResaleListed struct {
+	Header
+	ItemName    string `json:"item_name,omitempty"`
+	UserAssetID int    `json:"user_asset_id"`
+	Paid        int    `json:"paid"`
+	Price       int    `json:"price"`
+	Rule        string `json:"rule"`
+}
+
+// ResaleFailed is published when a sniped copy could not be put on sale and the resell was given up.
+type ResaleFailed struct {
+	Header
+	ItemName    string `json:"item_name,omitempty"`
+	UserAssetID int    `json:"user_asset_id"`
+	Paid        int    `json:"paid"`
+	Rule        string `json:"rule"`
+	Message     string `json:"message"`
+}
+
+// ListingSold is published when one of our resale listings disappeared from the inventory.
+type ListingSold struct {
+	Header
+	ItemName    string `json:"item_name,omitempty"`
+	UserAssetID int    `json:"user_asset_id"`
+	Price       int    `json:"price"`
+	Proceeds    int    `json:"proceeds"` // Price minus the marketplace fee
+}
+
+// ListingRepriced is published after one of our resale listings was lowered to undercut another seller.
+type ListingRepriced struct {
+	Header
+	ItemName    string `json:"item_name,omitempty"`
+	UserAssetID int    `json:"user_asset_id"`
+	OldPrice    int    `json:"old_price"`
+	Price       int    `json:"price"`
+	Lowest      int    `json:"lowest"` // Competing listing that was undercut
+	Floor       int    `json:"floor"`
+}
+
+// ItemDiscovered is published when catalog discovery finds an item listed far enough below its RAP.
+// Watched tells whether it was added to the watchlist (until Until) or only alerted on.
+type ItemDiscovered struct {
+	Header
+	ItemName string    `json:"item_name"`
+	Creator  string    `json:"creator,omitempty"`
+	Price    int       `json:"price"`
+	RAP      int       `json:"rap"`
+	Discount float64   `json:"discount"` // Percent below RAP
+	Target   int       `json:"target,omitempty"`
+	Watched  bool      `json:"watched"`
+	Until    time.Time `json:"until,omitempty"`
+}
+
+// PriceAlert is published when a listing hits the target of an entry that does not buy on its own.
+// ApprovalID is set when the purchase waits for an approval until Expires.
+type PriceAlert struct {
+	Header
+	Listing
+	ItemName   string    `json:"item_name"`
+	ItemType   string    `json:"item_type"`
+	Target     int       `json:"target"`
+	ApprovalID string    `json:"approval_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"` // Why the purchase needs an approval
+	Expires    time.Time `json:"expires,omitempty"`
+}
+
+// ApprovalDecided is published once a held purchase was approved, denied or expired. Decision is
+// one of the approval package decisions, or stale when an approved listing was gone.
+type ApprovalDecided struct {
+	Header
+	Listing
+	ApprovalID string `json:"approval_id"`
+	ItemName   string `json:"item_name"`
+	Target     int    `json:"target"`
+	Decision   string `json:"decision"`
+}
+
+func (PriceObserved) Kind() Kind      { return KindPriceObserved }
+func (ScrapeFailed) Kind() Kind       { return KindScrapeFailed }
+func (TargetHit) Kind() Kind          { return KindTargetHit }
+func (PurchaseAttempted) Kind() Kind  { return KindPurchaseAttempted }
+func (PurchaseSucceeded) Kind() Kind  { return KindPurchaseSucceeded }
+func (PurchaseFailed) Kind() Kind     { return KindPurchaseFailed }
+func (WorkerStateChanged) Kind() Kind { return KindWorkerStateChanged }
+func (ResaleListed) Kind() Kind       { return KindResaleListed }
+func (ResaleFailed) Kind() Kind       { return KindResaleFailed }
+func (ListingSold) Kind() Kind        { return KindListingSold }
+func (ListingRepriced) Kind() Kind    { return KindListingRepriced }
+func (ItemDiscovered) Kind() Kind     { return KindItemDiscovered }
+func (PriceAlert) Kind() Kind         { return KindPriceAlert }
+func (ApprovalDecided) Kind() Kind    { return KindApprovalDecided }
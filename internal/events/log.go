@@ -0,0 +1,80 @@
+package events
+
+import (
+	"fmt"
+	"sniper/internal/logging"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// LogEvents writes every event to the log of the subsystem it belongs to, with its attempt id.
+func LogEvents() {
+	Subscribe("log", 0, func(event Event) {
+		meta := event.Meta()
+		attempt := func(subsystem string) *log.Logger {
+			if meta.Attempt == "" {
+				return logging.For(subsystem).With("Limited ID", meta.LimitedID)
+			}
+			return logging.Attempt(subsystem, meta.Attempt).With("Limited ID", meta.LimitedID)
+		}
+
+		switch e := event.(type) {
+		case PriceObserved:
+			attempt("scraper").Debug("Listing scraped", "Price", e.Price, "Seller ID", e.SellerID, "User Asset ID", e.UserAssetID, "Latency", e.Latency)
+		case ScrapeFailed:
+			attempt("scraper").Error("Could not scrape item details", "Error", e.Err)
+		case TargetHit:
+			attempt("worker").Warn("Lower Than Expected Price Detected.", "Price", e.Price, "Target", e.Target)
+		case PurchaseAttempted:
+			attempt("purchase").Info("Attempting purchase", "Price", e.Price, "Product ID", e.ProductID, "Seller ID", e.SellerID)
+		case PurchaseSucceeded:
+			attempt("purchase").Warn("Sniped Successfully Executed", "Price", e.Price, "Latency", e.Latency, "Balance", e.Balance)
+		case PurchaseFailed:
+			switch {
+			case !e.Rejected:
+				attempt("purchase").Error("Purchase Error", "Error", e.Message)
+			case e.Shortfall > 0:
+				attempt("purchase").Warn("Not enough Robux for the purchase.", "Shortfall", e.Shortfall)
+			default:
+				attempt("purchase").Warn("Purchase Failure", "Message", e.Message)
+			}
+		case ResaleListed:
+			attempt("resell").Info("💸 Listed for resale", "User Asset ID", e.UserAssetID, "Price", e.Price, "Paid", e.Paid, "Rule", e.Rule)
+		case ResaleFailed:
+			attempt("resell").Error("Could not list for resale", "User Asset ID", e.UserAssetID, "Rule", e.Rule, "Error", e.Message)
+		case ListingSold:
+			attempt("listings").Info("💰 Listing sold", "User Asset ID", e.UserAssetID, "Price", e.Price, "Proceeds", e.Proceeds)
+		case ListingRepriced:
+			attempt("listings").Info("Listing repriced", "User Asset ID", e.UserAssetID, "Old Price", e.OldPrice, "Price", e.Price, "Lowest", e.Lowest)
+		case ItemDiscovered:
+			discovery := attempt("discovery").With("Name", e.ItemName, "Price", e.Price, "RAP", e.RAP, "Discount", fmt.Sprintf("%.1f%%", e.Discount))
+			if e.Watched {
+				discovery.Info("🔎 Deal found, watching", "Target", e.Target, "Until", e.Until.Format(time.Kitchen))
+			} else {
+				discovery.Info("🔎 Deal found")
+			}
+		case PriceAlert:
+			if e.ApprovalID != "" {
+				attempt("worker").Warn("🔔 Target hit, waiting for approval", "Price", e.Price, "Target", e.Target, "Approval ID", e.ApprovalID, "Reason", e.Reason)
+			} else {
+				attempt("worker").Warn("🔔 Target hit, alert only", "Price", e.Price, "Target", e.Target, "Seller ID", e.SellerID)
+			}
+		case ApprovalDecided:
+			attempt("approval").Info("Purchase approval decided", "Approval ID", e.ApprovalID, "Decision", e.Decision, "Price", e.Price)
+		case WorkerStateChanged:
+			worker := attempt("worker")
+			switch e.State {
+			case StateActive:
+				if e.Source != "" {
+					worker = worker.With("Source", e.Source)
+				}
+				worker.Info("Worker Activated", "Name", e.ItemName, "Target", e.Target, "Reason", e.Reason)
+			case StatePaused:
+				worker.Warn("Worker Paused", "Target", e.Target, "Reason", e.Reason)
+			case StateStopped:
+				worker.Warn("Worker Stopped", "Reason", e.Reason)
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package inventory
+
+import (
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("inventory")
+
+const DefaultRefreshInterval = 5 * time.Minute
+
+// How long a copy we bought is counted without the inventory API listing it yet.
+const pendingFor = 10 * time.Minute
+
+type pendingCopy struct {
+	copy   scraper.Collectible
+	at     time.Time
+	expect int // Copies of the asset the API lists once it caught up, for copies without a user asset id
+}
+
+var (
+	copies  = map[string][]scraper.Collectible{} // Asset ID -> owned copies
+	pending = map[int]pendingCopy{}              // User asset ID -> copy bought by this process
+	fetched = map[string]int{}                   // Asset ID -> copies listed by the last fetch
+	known   bool
+	mu      sync.RWMutex
+	refresh = make(chan struct{}, 1)
+
+	unknownCopies int // Last key handed to a pending copy without a user asset id
+)
+
+// Start fetches the owned collectibles right away, then every interval and after every purchase.
+func Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+
+	fetch()
+
+	// The inventory API lags behind a purchase, a fresh fetch only confirms the count Add already bumped
+	events.Subscribe("inventory", 0, func(events.Event) {
+		Refresh()
+	}, events.KindPurchaseSucceeded)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-refresh:
+			}
+			fetch()
+		}
+	}()
+}
+
+func fetch() {
+	if !session.Active() {
+		return
+	}
+
+	owned, err := scraper.FetchCollectibles(session.Cookie(), session.User().Id)
+	if err != nil {
+		logger.Error("Could not fetch owned collectibles", "Error", err)
+		return
+	}
+	Set(owned)
+}
+
+// Set replaces the known inventory. Copies bought recently that the API does not list yet are kept.
+func Set(owned []scraper.Collectible) {
+	byAsset := map[string][]scraper.Collectible{}
+	listed := map[int]bool{}
+	for _, c := range owned {
+		id := strconv.Itoa(c.AssetID)
+		byAsset[id] = append(byAsset[id], c)
+		listed[c.UserAssetID] = true
+	}
+
+	mu.Lock()
+	counts := map[string]int{}
+	for id, owned := range byAsset {
+		counts[id] = len(owned)
+	}
+
+	for key, p := range pending {
+		id := strconv.Itoa(p.copy.AssetID)
+		arrived := listed[key] || (key < 0 && counts[id] >= p.expect)
+		if arrived || time.Since(p.at) > pendingFor {
+			delete(pending, key)
+			continue
+		}
+		byAsset[id] = append(byAsset[id], p.copy)
+	}
+
+	first := !known
+	copies = byAsset
+	fetched = counts
+	known = true
+	mu.Unlock()
+
+	if first {
+		logger.Info("🎒 Inventory Loaded", "Collectibles", len(owned), "Items", len(byAsset))
+	}
+}
+
+// Refresh asks for a new fetch as soon as possible, it never blocks.
+func Refresh() {
+	select {
+	case refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Add counts a copy bought by this process until the next fetch picks it up.
+func Add(assetID string, userAssetID int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	id, _ := strconv.Atoi(assetID)
+	c := scraper.Collectible{AssetID: id, UserAssetID: userAssetID}
+	copies[assetID] = append(copies[assetID], c)
+
+	p := pendingCopy{copy: c, at: time.Now()}
+	key := userAssetID
+
+	// UGC purchases don't tell the user asset id, the copy counts as arrived once the API lists one more
+	if key == 0 {
+		p.expect = fetched[assetID] + 1
+		for k, other := range pending {
+			if k < 0 && other.copy.AssetID == id {
+				p.expect++
+			}
+		}
+		unknownCopies--
+		key = unknownCopies
+	}
+	pending[key] = p
+}
+
+// Owned returns how many copies of an asset the user owns, and whether the inventory has been fetched at all.
+func Owned(assetID string) (int, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(copies[assetID]), known
+}
+
+// Copies returns the owned copies of an asset.
+func Copies(assetID string) []scraper.Collectible {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]scraper.Collectible{}, copies[assetID]...)
+}
@@ -0,0 +1,223 @@
+package ledger
+
+import (
+	"fmt"
+	"sniper/internal/eventlog"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("ledger")
+
+// nearMiss is the last listing logged as a near miss, so a listing is only logged once.
+type nearMiss struct {
+	Price    int
+	SellerID int
+}
+
+var lastNearMisses = sync.Map{}
+
+// Start writes purchases, failures, errors, near misses and resale activity from the event bus into the event log.
+// nearMissPercent is how far above the target a listing still counts as a near miss, 0 disables them.
+//
+// Events are written one at a time in the order they were published, a purchase's RAP lookup
+// holds up the events behind it but never reorders them. Near misses come from the lossy poll
+// stream and have their own subscriber, so that wait never makes polls drop.
+func Start(nearMissPercent float64) {
+	events.Subscribe("ledger-near-misses", 0, func(event events.Event) {
+		recordNearMiss(nearMissPercent, event.(events.PriceObserved))
+	}, events.KindPriceObserved)
+
+	events.Subscribe("ledger", 0, func(event events.Event) {
+		switch e := event.(type) {
+		case events.ScrapeFailed:
+			eventlog.Record(eventlog.Event{
+				Time:      e.Time,
+				Type:      eventlog.TypeError,
+				LimitedID: e.LimitedID,
+				Attempt:   e.Attempt,
+				ErrorType: eventlog.ErrorScrape,
+				Message:   e.Err.Error(),
+			})
+		case events.PurchaseSucceeded:
+			recordPurchase(e)
+		case events.PurchaseFailed:
+			recordFailure(e)
+		case events.ResaleListed:
+			eventlog.Record(eventlog.Event{
+				Time:        e.Time,
+				Type:        eventlog.TypeResale,
+				LimitedID:   e.LimitedID,
+				Attempt:     e.Attempt,
+				Price:       e.Price,
+				Paid:        e.Paid,
+				UserAssetID: e.UserAssetID,
+				Message:     e.Rule,
+			})
+		case events.ResaleFailed:
+			eventlog.Record(eventlog.Event{
+				Time:        e.Time,
+				Type:        eventlog.TypeResaleFailure,
+				LimitedID:   e.LimitedID,
+				Attempt:     e.Attempt,
+				Paid:        e.Paid,
+				UserAssetID: e.UserAssetID,
+				Message:     e.Message,
+			})
+		case events.ListingSold:
+			eventlog.Record(eventlog.Event{
+				Time:        e.Time,
+				Type:        eventlog.TypeSale,
+				LimitedID:   e.LimitedID,
+				Price:       e.Price,
+				Proceeds:    e.Proceeds,
+				UserAssetID: e.UserAssetID,
+			})
+		case events.ListingRepriced:
+			eventlog.Record(eventlog.Event{
+				Time:        e.Time,
+				Type:        eventlog.TypeReprice,
+				LimitedID:   e.LimitedID,
+				Price:       e.Price,
+				Target:      e.Floor,
+				UserAssetID: e.UserAssetID,
+				Message:     fmt.Sprintf("lowered from R$ %d to undercut R$ %d", e.OldPrice, e.Lowest),
+			})
+		case events.ItemDiscovered:
+			eventlog.Record(eventlog.Event{
+				Time:      e.Time,
+				Type:      eventlog.TypeDiscovery,
+				LimitedID: e.LimitedID,
+				Price:     e.Price,
+				RAP:       e.RAP,
+				Target:    e.Target,
+				Message:   discoveryNote(e),
+			})
+		case events.PriceAlert:
+			eventlog.Record(eventlog.Event{
+				Time:        e.Time,
+				Type:        eventlog.TypeAlert,
+				LimitedID:   e.LimitedID,
+				Attempt:     e.Attempt,
+				Price:       e.Price,
+				Target:      e.Target,
+				SellerID:    e.SellerID,
+				UserAssetID: e.UserAssetID,
+				Message:     e.ApprovalID,
+			})
+		case events.ApprovalDecided:
+			eventlog.Record(eventlog.Event{
+				Time:      e.Time,
+				Type:      eventlog.TypeApproval,
+				LimitedID: e.LimitedID,
+				Attempt:   e.Attempt,
+				Price:     e.Price,
+				Target:    e.Target,
+				SellerID:  e.SellerID,
+				Message:   fmt.Sprintf("%s %s", e.ApprovalID, e.Decision),
+			})
+		}
+	}, events.KindScrapeFailed, events.KindPurchaseSucceeded, events.KindPurchaseFailed,
+		events.KindResaleListed, events.KindResaleFailed, events.KindListingSold, events.KindListingRepriced,
+		events.KindItemDiscovered, events.KindPriceAlert, events.KindApprovalDecided)
+}
+
+func recordPurchase(e events.PurchaseSucceeded) {
+	// RAP is only needed for the report, fetched here so it never delays the purchase
+	rap := fetchRAP(e)
+
+	eventlog.Record(eventlog.Event{
+		Time:        e.Time,
+		Type:        eventlog.TypePurchase,
+		LimitedID:   e.LimitedID,
+		Attempt:     e.Attempt,
+		Price:       e.Price,
+		Target:      e.Target,
+		RAP:         rap,
+		Balance:     e.Balance,
+		SellerID:    e.SellerID,
+		UserAssetID: e.UserAssetID,
+	})
+}
+
+// fetchRAP returns the RAP of a bought item, 0 for bundles (they have no resale data) or on errors.
+func fetchRAP(e events.PurchaseSucceeded) int {
+	if e.ItemType == parser.TypeBundle {
+		return 0
+	}
+
+	resale, err := scraper.FetchResaleData(session.Cookie(), e.LimitedID)
+	if err != nil {
+		logging.Attempt("ledger", e.Attempt).Error("Could not fetch resale data", "Limited ID", e.LimitedID, "Error", err)
+		eventlog.Record(eventlog.Event{
+			Type:      eventlog.TypeError,
+			LimitedID: e.LimitedID,
+			Attempt:   e.Attempt,
+			ErrorType: eventlog.ErrorResale,
+			Message:   err.Error(),
+		})
+		return 0
+	}
+	return resale.RecentAveragePrice
+}
+
+// discoveryNote describes a discovered item for review, the event log has no name or action field.
+func discoveryNote(e events.ItemDiscovered) string {
+	action := "alerted"
+	if e.Watched {
+		action = "watched until " + e.Until.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s, %.1f%% below RAP, %s", e.ItemName, e.Discount, action)
+}
+
+func recordFailure(e events.PurchaseFailed) {
+	event := eventlog.Event{
+		Time:      e.Time,
+		Type:      eventlog.TypePurchaseFailure,
+		LimitedID: e.LimitedID,
+		Attempt:   e.Attempt,
+		Price:     e.Price,
+		Target:    e.Target,
+		SellerID:  e.SellerID,
+		Message:   e.Message,
+	}
+
+	// The request itself failed, Roblox never answered with a verdict
+	if !e.Rejected {
+		event.Type = eventlog.TypeError
+		event.ErrorType = eventlog.ErrorPurchase
+	}
+
+	eventlog.Record(event)
+}
+
+// recordNearMiss logs listings that came within percent of the target without reaching it.
+func recordNearMiss(percent float64, e events.PriceObserved) {
+	if percent <= 0 || e.Price <= e.Target {
+		return
+	}
+
+	if float64(e.Price) > float64(e.Target)*(1+percent/100) {
+		return
+	}
+
+	listing := nearMiss{Price: e.Price, SellerID: e.SellerID}
+	if previous, ok := lastNearMisses.Swap(e.LimitedID, listing); ok && previous.(nearMiss) == listing {
+		return
+	}
+
+	eventlog.Record(eventlog.Event{
+		Time:      e.Time,
+		Type:      eventlog.TypeNearMiss,
+		LimitedID: e.LimitedID,
+		Attempt:   e.Attempt,
+		Price:     e.Price,
+		Target:    e.Target,
+		SellerID:  e.SellerID,
+	})
+}
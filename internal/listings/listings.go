@@ -0,0 +1,324 @@
+package listings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sniper/internal/config"
+	"sniper/internal/csrf"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/purchase"
+	"sniper/internal/rules"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var logger = logging.For("listings")
+
+// A copy must be missing from this many polls in a row before it counts as sold, a single
+// response can leave out a copy we still own.
+const soldAfterPolls = 2
+
+// Listing is one of our copies on sale, as last seen.
+type Listing struct {
+	LimitedID   string    `json:"limited_id"`
+	ItemName    string    `json:"item_name,omitempty"`
+	UserAssetID int       `json:"user_asset_id"`
+	Price       int       `json:"price"`
+	Since       time.Time `json:"since"`
+	Missing     int       `json:"missing,omitempty"` // Polls in a row the copy was not among ours
+}
+
+var (
+	cfg       config.ListingsConfig
+	floors    = map[string]int{} // Limited ID -> lowest price to reprice to
+	watched   []string
+	listed    = map[int]*Listing{} // User asset ID -> listing
+	itemNames = map[string]string{}
+	mu        sync.Mutex
+)
+
+// Start watches our resale listings of the items in the limiteds file (and any left in the
+// state file). A listed copy that leaves the inventory for soldAfterPolls polls in a row is
+// reported as sold, trades included.
+// With reprice on, listings are lowered to stay the cheapest, never below the item's floor.
+func Start(listingsConfig config.ListingsConfig, limiteds []parser.LimitedInfo) error {
+	if !listingsConfig.Enabled {
+		return nil
+	}
+
+	mu.Lock()
+	cfg = listingsConfig
+	seen := map[string]bool{}
+	for _, limited := range limiteds {
+		// UGC limiteds are resold through the marketplace, not the resellable copies this watches
+		if limited.Kind == parser.KindUGC {
+			continue
+		}
+		floors[limited.Id] = limited.Floor
+		if !seen[limited.Id] {
+			seen[limited.Id] = true
+			watched = append(watched, limited.Id)
+		}
+	}
+
+	restored, err := readState()
+	if err != nil {
+		mu.Unlock()
+		return err
+	}
+	for _, listing := range restored {
+		listed[listing.UserAssetID] = listing
+		if !seen[listing.LimitedID] {
+			seen[listing.LimitedID] = true
+			watched = append(watched, listing.LimitedID)
+		}
+	}
+	mu.Unlock()
+
+	logger.Info("Watching resale listings", "Items", len(watched), "Listed", len(restored), "Reprice", cfg.Reprice)
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			check()
+			<-ticker.C
+		}
+	}()
+
+	return nil
+}
+
+// Listed returns our listings as last seen, cheapest first.
+func Listed() []Listing {
+	mu.Lock()
+	defer mu.Unlock()
+
+	all := make([]Listing, 0, len(listed))
+	for _, listing := range listed {
+		all = append(all, *listing)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Price < all[j].Price
+	})
+	return all
+}
+
+func check() {
+	if !session.Active() {
+		return
+	}
+
+	for _, id := range watched {
+		if err := checkItem(id); err != nil {
+			logger.Error("Could not check listings", "Limited ID", id, "Error", err)
+		}
+	}
+
+	mu.Lock()
+	persist()
+	mu.Unlock()
+}
+
+// checkItem compares the copies of an item on sale with what was seen last time.
+func checkItem(id string) error {
+	cookie := session.Cookie()
+
+	copies, err := scraper.FetchResellableCopies(cookie, id, session.User().Id)
+	if err != nil {
+		return fmt.Errorf("could not fetch resellable copies: %w", err)
+	}
+
+	owned := map[int]scraper.ResellableCopy{}
+	for _, c := range copies {
+		owned[c.UserAssetID] = c
+	}
+
+	mu.Lock()
+	var sold []Listing
+	for userAssetID, listing := range listed {
+		if listing.LimitedID != id {
+			continue
+		}
+
+		c, ok := owned[userAssetID]
+		switch {
+		case !ok:
+			listing.Missing++
+			if listing.Missing >= soldAfterPolls {
+				sold = append(sold, *listing)
+				delete(listed, userAssetID)
+			}
+		case c.Price <= 0:
+			// Taken off sale by hand, nothing to report
+			delete(listed, userAssetID)
+		default:
+			listing.Missing = 0
+			listing.Price = c.Price
+		}
+	}
+
+	var onSale []*Listing
+	for _, c := range copies {
+		if c.Price <= 0 {
+			continue
+		}
+		listing, ok := listed[c.UserAssetID]
+		if !ok {
+			listing = &Listing{LimitedID: id, UserAssetID: c.UserAssetID, Price: c.Price, Since: time.Now()}
+			listed[c.UserAssetID] = listing
+		}
+		onSale = append(onSale, listing)
+	}
+	mu.Unlock()
+
+	for _, listing := range sold {
+		events.Publish(events.ListingSold{
+			Header:      events.Stamp("", listing.LimitedID),
+			ItemName:    itemName(listing),
+			UserAssetID: listing.UserAssetID,
+			Price:       listing.Price,
+			Proceeds:    rules.Proceeds(listing.Price, cfg.FeePercent),
+		})
+	}
+
+	if cfg.Reprice && floors[id] > 0 && len(onSale) > 0 {
+		return reprice(id, onSale)
+	}
+	return nil
+}
+
+// reprice lowers our listings of an item below the cheapest listing of another seller.
+func reprice(id string, onSale []*Listing) error {
+	cookie := session.Cookie()
+
+	lowest, err := scraper.ScrapeItemDetails(cookie, id)
+	if err != nil {
+		return fmt.Errorf("could not scrape the lowest listing: %w", err)
+	}
+
+	// Already the cheapest
+	if lowest.SellerID == session.User().Id {
+		return nil
+	}
+
+	for _, listing := range onSale {
+		mu.Lock()
+		current := listing.Price
+		mu.Unlock()
+
+		price, ok := rules.Reprice(current, lowest.Price, floors[id], cfg.Undercut)
+		if !ok {
+			continue
+		}
+
+		token, err := csrf.GetCSRF(cookie)
+		if err != nil {
+			return err
+		}
+		if err := purchase.SetResalePrice(token, cookie, id, listing.UserAssetID, price); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		listing.Price = price
+		mu.Unlock()
+
+		events.Publish(events.ListingRepriced{
+			Header:      events.Stamp("", id),
+			ItemName:    itemName(*listing),
+			UserAssetID: listing.UserAssetID,
+			OldPrice:    current,
+			Price:       price,
+			Lowest:      lowest.Price,
+			Floor:       floors[id],
+		})
+	}
+
+	return nil
+}
+
+// itemName looks up the name of an item once, notifications fall back to the id.
+func itemName(listing Listing) string {
+	if listing.ItemName != "" {
+		return listing.ItemName
+	}
+
+	mu.Lock()
+	name, ok := itemNames[listing.LimitedID]
+	mu.Unlock()
+	if ok {
+		return name
+	}
+
+	if details, err := scraper.FetchAssetDetails(session.Cookie(), listing.LimitedID); err == nil {
+		name = details.Name
+	}
+
+	mu.Lock()
+	itemNames[listing.LimitedID] = name
+	if current, ok := listed[listing.UserAssetID]; ok {
+		current.ItemName = name
+	}
+	mu.Unlock()
+	return name
+}
+
+// persist writes the known listings so a sale during downtime is still noticed, callers hold mu.
+func persist() {
+	if cfg.StateFile == "" {
+		return
+	}
+
+	all := make([]*Listing, 0, len(listed))
+	for _, listing := range listed {
+		all = append(all, listing)
+	}
+
+	data, err := json.Marshal(all)
+	if err != nil {
+		logger.Error("Failed to marshal listings", "Error", err)
+		return
+	}
+
+	// Write to a temporary file first so a crash never leaves a half-written state behind
+	tmp := cfg.StateFile + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(cfg.StateFile), 0o755); err == nil {
+		err = os.WriteFile(tmp, data, 0o600)
+		if err == nil {
+			err = os.Rename(tmp, cfg.StateFile)
+		}
+		if err != nil {
+			logger.Error("Failed to persist listings", "Error", err)
+		}
+	}
+}
+
+func readState() ([]*Listing, error) {
+	if cfg.StateFile == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cfg.StateFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listings: %w", err)
+	}
+
+	var restored []*Listing
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return nil, fmt.Errorf("failed to decode listings: %w", err)
+	}
+	return restored, nil
+}
@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sniper/internal/redact"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// Formats accepted by Init.
+const (
+	FormatPretty = "pretty"
+	FormatJSON   = "json"
+)
+
+var (
+	loggers      = map[string]*log.Logger{}
+	formatter    = log.TextFormatter
+	defaultLevel = log.InfoLevel
+	levels       = map[string]log.Level{}
+	verbose      bool // Debug everywhere, whatever the configured levels
+	mu           sync.Mutex
+
+	// Every log line goes through the redaction layer, secrets never reach the terminal or a log file
+	output = redact.Writer(os.Stderr)
+)
+
+func init() {
+	log.SetOutput(output)
+}
+
+// For returns the logger of a subsystem, every line it writes carries a "subsystem" key.
+// Loggers can be created before Init, they pick up the format and level once it runs.
+func For(subsystem string) *log.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logger, ok := loggers[subsystem]; ok {
+		return logger
+	}
+
+	logger := log.NewWithOptions(output, log.Options{
+		ReportTimestamp: true,
+		Formatter:       formatter,
+		Level:           levelFor(subsystem),
+	}).With("subsystem", subsystem)
+	loggers[subsystem] = logger
+
+	return logger
+}
+
+func levelFor(subsystem string) log.Level {
+	if verbose {
+		return log.DebugLevel
+	}
+	if level, ok := levels[subsystem]; ok {
+		return level
+	}
+	return defaultLevel
+}
+
+// ParseFormat validates a log format name.
+func ParseFormat(format string) (log.Formatter, error) {
+	switch strings.ToLower(format) {
+	case "", FormatPretty:
+		return log.TextFormatter, nil
+	case FormatJSON:
+		return log.JSONFormatter, nil
+	}
+	return log.TextFormatter, fmt.Errorf("unknown log format %q, use %s or %s", format, FormatPretty, FormatJSON)
+}
+
+// Init sets the format, the default level and per-subsystem levels of every logger,
+// including the default one used by the command line.
+func Init(format, level string, subsystemLevels map[string]string) error {
+	parsedFormat, err := ParseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	parsedLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	parsedLevels := map[string]log.Level{}
+	for subsystem, subsystemLevel := range subsystemLevels {
+		parsed, err := log.ParseLevel(subsystemLevel)
+		if err != nil {
+			return fmt.Errorf("invalid log level %q for %s: %w", subsystemLevel, subsystem, err)
+		}
+		parsedLevels[subsystem] = parsed
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	formatter = parsedFormat
+	defaultLevel = parsedLevel
+	levels = parsedLevels
+
+	log.SetFormatter(formatter)
+	applyLevels()
+
+	for _, logger := range loggers {
+		logger.SetFormatter(formatter)
+	}
+
+	return nil
+}
+
+// SetVerbose switches every logger to debug while running, and back to the configured levels.
+func SetVerbose(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	verbose = on
+	applyLevels()
+}
+
+// Verbose reports whether SetVerbose turned debug output on.
+func Verbose() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return verbose
+}
+
+// SetOutput sends every log line to w instead of stderr, still through the redaction layer.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	output = redact.Writer(w)
+	log.SetOutput(output)
+	for _, logger := range loggers {
+		logger.SetOutput(output)
+	}
+}
+
+// applyLevels sets the level of every logger, callers hold mu.
+func applyLevels() {
+	log.SetLevel(levelFor(""))
+	for subsystem, logger := range loggers {
+		logger.SetLevel(levelFor(subsystem))
+	}
+}
+
+// NewAttemptID returns a short random id tying together the log lines of one poll-to-purchase attempt.
+func NewAttemptID() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "000000000000"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Attempt returns the subsystem logger with the attempt id attached.
+func Attempt(subsystem, attemptID string) *log.Logger {
+	return For(subsystem).With("attempt", attemptID)
+}
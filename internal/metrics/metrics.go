@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"sniper/internal/events"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type LastCheck struct {
+	TimeTaken time.Duration
+}
+
+var (
+	PollCounts      = sync.Map{} // Limited ID -> *atomic.Int64, polls since startup
+	IterationChecks = sync.Map{} // Limited ID -> LastCheck, latency of the latest poll
+	PurchasedItems  = sync.Map{} // Limited ID -> struct{}
+	FailedItems     = sync.Map{} // Limited ID -> struct{}, items with a failed purchase
+)
+
+// Start keeps the counters up to date from the event bus.
+func Start() {
+	events.Subscribe("metrics", 0, func(event events.Event) {
+		id := event.Meta().LimitedID
+
+		switch e := event.(type) {
+		case events.PriceObserved:
+			countPoll(id)
+			IterationChecks.Store(id, LastCheck{TimeTaken: e.Latency})
+		case events.ScrapeFailed:
+			countPoll(id)
+		case events.PurchaseSucceeded:
+			PurchasedItems.Store(id, struct{}{})
+		case events.PurchaseFailed:
+			FailedItems.Store(id, struct{}{})
+		}
+	}, events.KindPriceObserved, events.KindScrapeFailed, events.KindPurchaseSucceeded, events.KindPurchaseFailed)
+}
+
+func countPoll(limitedID string) {
+	counter, _ := PollCounts.LoadOrStore(limitedID, new(atomic.Int64))
+	counter.(*atomic.Int64).Add(1)
+}
+
+// HasFailed reports whether a purchase of the item failed before.
+func HasFailed(limitedID string) bool {
+	_, ok := FailedItems.Load(limitedID)
+	return ok
+}
@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"fmt"
+	"sniper/internal/approval"
+	"sniper/internal/eventlog"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sniper/internal/webhook"
+)
+
+// Start turns purchase, resale and sale events from the bus into webhook notifications.
+// Thumbnails and RAP are fetched here, after the purchase already went through. Notifications are
+// queued in the order of their events, every lookup has a timeout so one never stalls the rest.
+func Start() {
+	events.Subscribe("notify", 0, func(event events.Event) {
+		switch e := event.(type) {
+		case events.PurchaseSucceeded:
+			details := webhook.SnipeDetails{
+				LimitedID:           e.LimitedID,
+				ItemName:            e.ItemName,
+				ItemType:            e.ItemType,
+				SellerID:            e.SellerID,
+				Price:               e.Price,
+				Target:              e.Target,
+				Balance:             e.Balance,
+				Latency:             e.Latency,
+				DetectionToPurchase: e.DetectionToPurchase,
+			}
+			// Both lookups run side by side, the notification only waits for the slower one
+			rap := make(chan int, 1)
+			go func() {
+				rap <- fetchRAP(e.LimitedID, e.ItemType)
+			}()
+			details.ThumbnailURL = thumbnail(e.Attempt, e.LimitedID, e.ItemType)
+			details.RAP = <-rap
+			snipe(e.Attempt, webhook.EventSuccess, "Limited Snipe Success", 0xF58A42, details)
+
+		case events.PurchaseFailed:
+			details := webhook.SnipeDetails{
+				LimitedID: e.LimitedID,
+				ItemName:  e.ItemName,
+				ItemType:  e.ItemType,
+				SellerID:  e.SellerID,
+				Price:     e.Price,
+				Target:    e.Target,
+				Balance:   e.Balance,
+				Message:   e.Message,
+			}
+			details.ThumbnailURL = thumbnail(e.Attempt, e.LimitedID, e.ItemType)
+			if e.Rejected {
+				snipe(e.Attempt, webhook.EventFailure, "Purchase Failure", 0x8115ed, details)
+			} else {
+				snipe(e.Attempt, webhook.EventError, "Error", 0xd11197, details)
+			}
+
+		case events.ResaleListed:
+			resale(e.Attempt, "Listed For Resale", 0x3ba55d, webhook.ResaleDetails{
+				LimitedID:   e.LimitedID,
+				ItemName:    e.ItemName,
+				UserAssetID: e.UserAssetID,
+				Paid:        e.Paid,
+				Price:       e.Price,
+				Profit:      e.Price - e.Paid,
+				Rule:        e.Rule,
+			})
+
+		case events.ResaleFailed:
+			resale(e.Attempt, "Resale Failed", 0xd11197, webhook.ResaleDetails{
+				LimitedID:   e.LimitedID,
+				ItemName:    e.ItemName,
+				UserAssetID: e.UserAssetID,
+				Paid:        e.Paid,
+				Rule:        e.Rule,
+				Message:     e.Message,
+			})
+
+		case events.ListingSold:
+			sale(e)
+
+		case events.ItemDiscovered:
+			discovery(e)
+
+		case events.PriceAlert:
+			alert(e)
+		}
+	}, events.KindPurchaseSucceeded, events.KindPurchaseFailed, events.KindResaleListed, events.KindResaleFailed, events.KindListingSold,
+		events.KindItemDiscovered, events.KindPriceAlert)
+}
+
+// sale queues the notification of one of our listings selling.
+func sale(e events.ListingSold) {
+	details := webhook.SaleDetails{
+		LimitedID:   e.LimitedID,
+		ItemName:    e.ItemName,
+		ItemURL:     webhook.ItemURL(e.LimitedID),
+		UserAssetID: e.UserAssetID,
+		Price:       e.Price,
+		Proceeds:    e.Proceeds,
+	}
+	if details.ItemName == "" {
+		details.ItemName = details.LimitedID
+	}
+
+	description, err := webhook.Render(webhook.EventSale, details)
+	if err != nil {
+		logging.For("webhook").Error("Could not render webhook template", "Event", webhook.EventSale, "Error", err)
+		description = fmt.Sprintf("Limited ID: `%s`\nPrice: `%d`", details.LimitedID, details.Price)
+	}
+
+	webhook.Notify(webhook.Message{
+		Event:       webhook.EventSale,
+		Title:       "Listing Sold",
+		Description: description,
+		URL:         details.ItemURL,
+		Color:       0xf1c40f,
+	})
+}
+
+// resale queues the notification of a resell outcome.
+func resale(attempt, title string, color int, details webhook.ResaleDetails) {
+	details.ItemURL = webhook.ItemURL(details.LimitedID)
+	if details.ItemName == "" {
+		details.ItemName = details.LimitedID
+	}
+
+	description, err := webhook.Render(webhook.EventResale, details)
+	if err != nil {
+		logging.Attempt("webhook", attempt).Error("Could not render webhook template", "Event", webhook.EventResale, "Error", err)
+		description = fmt.Sprintf("Limited ID: `%s`\nMessage: `%s`", details.LimitedID, details.Message)
+	}
+
+	webhook.Notify(webhook.Message{
+		Event:       webhook.EventResale,
+		Title:       title,
+		Description: description,
+		URL:         details.ItemURL,
+		Color:       color,
+		Attempt:     attempt,
+	})
+}
+
+// discovery queues the notification of a deal found by catalog discovery.
+func discovery(e events.ItemDiscovered) {
+	details := webhook.DiscoveryDetails{
+		LimitedID: e.LimitedID,
+		ItemName:  e.ItemName,
+		ItemURL:   webhook.ItemURL(e.LimitedID),
+		Creator:   e.Creator,
+		Price:     e.Price,
+		RAP:       e.RAP,
+		Discount:  e.Discount,
+		Target:    e.Target,
+		Watched:   e.Watched,
+		Until:     e.Until,
+	}
+
+	description, err := webhook.Render(webhook.EventDiscovery, details)
+	if err != nil {
+		logging.For("webhook").Error("Could not render webhook template", "Event", webhook.EventDiscovery, "Error", err)
+		description = fmt.Sprintf("Limited ID: `%s`\nPrice: `%d`\nRAP: `%d`", details.LimitedID, details.Price, details.RAP)
+	}
+
+	title := "Deal Found"
+	if e.Watched {
+		title = "Deal Found, Watching"
+	}
+
+	webhook.Notify(webhook.Message{
+		Event:       webhook.EventDiscovery,
+		Title:       title,
+		Description: description,
+		URL:         details.ItemURL,
+		Color:       0x2f9fd8,
+	})
+}
+
+// fetchRAP returns the RAP of an item for a notification, 0 for bundles (they have no resale data) or on errors.
+func fetchRAP(limitedID, itemType string) int {
+	if itemType == parser.TypeBundle {
+		return 0
+	}
+	resale, err := scraper.FetchResaleData(session.Cookie(), limitedID)
+	if err != nil {
+		return 0
+	}
+	return resale.RecentAveragePrice
+}
+
+// thumbnail returns the thumbnail of an item, "" when it could not be fetched.
+func thumbnail(attempt, limitedID, itemType string) string {
+	data, err := scraper.GetThumbnail(limitedID, itemType)
+	if err != nil {
+		logging.Attempt("webhook", attempt).Error("Could not fetch thumbnail", "Limited ID", limitedID, "Error", err)
+		eventlog.Record(eventlog.Event{
+			Type:      eventlog.TypeError,
+			LimitedID: limitedID,
+			Attempt:   attempt,
+			ErrorType: eventlog.ErrorThumbnail,
+			Message:   err.Error(),
+		})
+		return ""
+	}
+	return data.ImageUrl
+}
+
+// snipe renders the event template for a snipe attempt and queues the notification.
+func snipe(attempt string, event webhook.EventKind, title string, color int, details webhook.SnipeDetails) {
+	log := logging.Attempt("webhook", attempt)
+
+	details = webhook.NewSnipeDetails(details)
+
+	description, err := webhook.Render(event, details)
+	if err != nil {
+		log.Error("Could not render webhook template", "Event", event, "Error", err)
+		description = fmt.Sprintf("Limited ID: `%s`\nMessage: `%s`", details.LimitedID, details.Message)
+	}
+
+	// Queued, delivery and retries happen in the background
+	webhook.Notify(webhook.Message{
+		Event:        event,
+		Title:        title,
+		Description:  description,
+		URL:          details.ItemURL,
+		Color:        color,
+		ThumbnailURL: details.ThumbnailURL,
+		Attempt:      attempt,
+	})
+}
+
+// alert queues the notification of a listing that hit the target of an alert or confirm entry.
+func alert(e events.PriceAlert) {
+	details := webhook.AlertDetails{
+		LimitedID:    e.LimitedID,
+		ItemName:     e.ItemName,
+		ItemURL:      webhook.ItemURL(e.LimitedID),
+		Price:        e.Price,
+		Target:       e.Target,
+		SellerID:     e.SellerID,
+		SerialNumber: e.SerialNumber,
+		ApprovalID:   e.ApprovalID,
+		Reason:       e.Reason,
+		Expires:      e.Expires,
+	}
+	if e.ItemType == parser.TypeBundle {
+		details.ItemURL = webhook.BundleURL(e.LimitedID)
+	}
+	if details.ItemName == "" {
+		details.ItemName = details.LimitedID
+	}
+	if e.ApprovalID != "" {
+		details.ApprovalURL = approval.Link(e.ApprovalID, e.Expires)
+	}
+
+	description, err := webhook.Render(webhook.EventAlert, details)
+	if err != nil {
+		logging.For("webhook").Error("Could not render webhook template", "Event", webhook.EventAlert, "Error", err)
+		description = fmt.Sprintf("Limited ID: `%s`\nPrice: `%d`\nTarget: `%d`", details.LimitedID, details.Price, details.Target)
+	}
+
+	title := "Price Alert"
+	if e.ApprovalID != "" {
+		title = "Approval Needed"
+	}
+
+	webhook.Notify(webhook.Message{
+		Event:       webhook.EventAlert,
+		Title:       title,
+		Description: description,
+		URL:         details.ItemURL,
+		Color:       0xf5a742,
+		Urgent:      e.ApprovalID != "",
+		Attempt:     e.Attempt,
+	})
+}
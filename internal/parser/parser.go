@@ -2,72 +2,312 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"strconv"
-  "bytes"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 )
 
 type LimitedInfo struct {
-	Price int    `json:"price"`
-	Id    string `json:"id"`
+	Price    int        `json:"price"`
+	Id       string     `json:"id"`
+	Kind     string     `json:"kind"`                // KindClassic or KindUGC, picks the scrape and purchase flow
+	Type     string     `json:"type"`                // TypeAsset or TypeBundle
+	MaxOwned int        `json:"max_owned,omitempty"` // Copies we may own at most, 0 for no limit
+	Resell   ResellRule `json:"resell"`              // How to price a sniped copy for resale, empty to keep it
+	Floor    int        `json:"floor,omitempty"`     // Lowest price our listings may be repriced to, 0 never reprices
+	Release  time.Time  `json:"release,omitempty"`   // Set for new drops: buy at list price once the item goes on sale
+	Source   string     `json:"source,omitempty"`    // Where the item came from, empty for the limiteds file
+	Action   string     `json:"action"`              // ActionBuy, ActionAlert or ActionConfirm
 }
 
+// SourceDiscovery tags items added to the watchlist by catalog discovery.
+const SourceDiscovery = "discovery"
+
+// IsDrop reports whether the item is watched for going on sale rather than for resale listings.
+func (l LimitedInfo) IsDrop() bool {
+	return !l.Release.IsZero()
+}
+
+// CheckResale rejects resale prices and floors at or below the target, the worker would find
+// our own listing as the cheapest one and keep trying to buy it back.
+func (l LimitedInfo) CheckResale() error {
+	if l.Resell.Mode == ResellFixed && l.Resell.Value <= float64(l.Price) {
+		return fmt.Errorf("resell=%s must be above the target of R$ %d", l.Resell, l.Price)
+	}
+	if l.Floor > 0 && l.Floor <= l.Price {
+		return fmt.Errorf("floor=%d must be above the target of R$ %d", l.Floor, l.Price)
+	}
+	return nil
+}
+
+// Item kinds, written as kind=<kind> in the limiteds file. Classic is the default.
+const (
+	KindClassic = "classic" // Limiteds sold through economy product ids
+	KindUGC     = "ugc"     // UGC limiteds sold through the marketplace collectible endpoints
+)
+
+// Actions taken when a listing hits the target, written as action=<action> in the limiteds file.
+// Buy is the default.
+const (
+	ActionBuy     = "buy"
+	ActionAlert   = "alert"              // Only notify, never buy
+	ActionConfirm = "alert-then-confirm" // Notify and buy once the purchase is approved
+)
+
+// Item types, written as type=<type> in the limiteds file. Asset is the default.
+const (
+	TypeAsset  = "asset"
+	TypeBundle = "bundle" // Limited bundles are always collectibles, they use the UGC flow
+)
+
+// CatalogType is the item type as the catalog and thumbnail APIs spell it.
+func (l LimitedInfo) CatalogType() string {
+	if l.Type == TypeBundle {
+		return "Bundle"
+	}
+	return "Asset"
+}
+
+// Resell modes, written as resell=<mode>:<value> in the limiteds file.
+const (
+	ResellFixed  = "fixed"  // resell=fixed:1500 lists for R$ 1500
+	ResellMarkup = "markup" // resell=markup:20 lists 20% above the price paid
+	ResellRAP    = "rap"    // resell=rap:95 lists at 95% of the RAP
+)
+
+type ResellRule struct {
+	Mode  string  `json:"mode"`
+	Value float64 `json:"value"`
+}
+
+// Enabled reports whether sniped copies should be put back on sale.
+func (r ResellRule) Enabled() bool {
+	return r.Mode != ""
+}
+
+func (r ResellRule) String() string {
+	if !r.Enabled() {
+		return "none"
+	}
+	return fmt.Sprintf("%s:%v", r.Mode, r.Value)
+}
+
+// LineFormatError describes a single bad line of a limiteds file.
 type LineFormatError struct {
-	err string
+	Line int
+	err  string
 }
 
 func (e *LineFormatError) Error() string {
-	return e.err
+	return fmt.Sprintf("line %d: %s", e.Line, e.err)
 }
 
-// FromFile reads the file contents line by line from the provided path
-// and parses each line into LimitedInfo. Each line should follow the syntax: id<int>, price<int>.
-func FromFile(path string) ([]LimitedInfo, error) {
+// ParseLine parses a single "id, price" line, optionally followed by "key=value" options
+// (e.g. "1234, 500, max_owned=2").
+func ParseLine(line string) (LimitedInfo, error) {
+	// Split the line into parts (id, price and options)
+	parts := strings.Split(line, ",")
+	if len(parts) < 2 {
+		return LimitedInfo{}, fmt.Errorf("invalid line format: %s", line)
+	}
+
+	id := strings.TrimSpace(parts[0])
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return LimitedInfo{}, fmt.Errorf("invalid limited id: %q", id)
+	}
+
+	price, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return LimitedInfo{}, fmt.Errorf("failed to parse price: %w", err)
+	}
+
+	if price <= 0 {
+		return LimitedInfo{}, fmt.Errorf("price must be positive, got %d", price)
+	}
+
+	info := LimitedInfo{
+		Id:     id,
+		Price:  price,
+		Type:   TypeAsset,
+		Action: ActionBuy,
+	}
+
+	for _, option := range parts[2:] {
+		if err := parseOption(&info, strings.TrimSpace(option)); err != nil {
+			return LimitedInfo{}, err
+		}
+	}
+
+	if info.Type == TypeBundle {
+		if info.Kind == KindClassic {
+			return LimitedInfo{}, fmt.Errorf("bundles are always bought as collectibles, drop kind=classic")
+		}
+		info.Kind = KindUGC
+
+		// The collectibles inventory only lists assets, owned bundles can't be counted
+		if info.MaxOwned > 0 {
+			return LimitedInfo{}, fmt.Errorf("max_owned is not supported for bundles")
+		}
+		if info.IsDrop() {
+			return LimitedInfo{}, fmt.Errorf("release is not supported for bundles")
+		}
+	}
+	if info.Kind == "" {
+		info.Kind = KindClassic
+	}
+
+	// Resale listings go through the classic resellable copies endpoints
+	if info.Kind == KindUGC && (info.Resell.Enabled() || info.Floor > 0) {
+		return LimitedInfo{}, fmt.Errorf("resell and floor are only supported for classic limiteds")
+	}
+	if err := info.CheckResale(); err != nil {
+		return LimitedInfo{}, err
+	}
+
+	return info, nil
+}
+
+// parseOption applies a single "key=value" option of a line.
+func parseOption(info *LimitedInfo, option string) error {
+	key, value, ok := strings.Cut(option, "=")
+	if !ok {
+		return fmt.Errorf("invalid option %q, use key=value", option)
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+	switch key {
+	case "kind":
+		switch value {
+		case KindClassic, KindUGC:
+			info.Kind = value
+		default:
+			return fmt.Errorf("unknown kind %q, use classic or ugc", value)
+		}
+	case "type":
+		switch value {
+		case TypeAsset, TypeBundle:
+			info.Type = value
+		default:
+			return fmt.Errorf("unknown type %q, use asset or bundle", value)
+		}
+	case "action":
+		switch value {
+		case ActionBuy, ActionAlert, ActionConfirm:
+			info.Action = value
+		default:
+			return fmt.Errorf("unknown action %q, use buy, alert or alert-then-confirm", value)
+		}
+	case "release":
+		release, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("release must be an RFC 3339 time like 2026-11-01T18:00:00Z, got %q", value)
+		}
+		info.Release = release
+	case "max_owned":
+		max, err := strconv.Atoi(value)
+		if err != nil || max <= 0 {
+			return fmt.Errorf("max_owned must be a positive number, got %q", value)
+		}
+		info.MaxOwned = max
+	case "resell":
+		rule, err := parseResellRule(value)
+		if err != nil {
+			return err
+		}
+		info.Resell = rule
+	case "floor":
+		floor, err := strconv.Atoi(value)
+		if err != nil || floor <= 0 {
+			return fmt.Errorf("floor must be a positive number, got %q", value)
+		}
+		info.Floor = floor
+	default:
+		return fmt.Errorf("unknown option %q", key)
+	}
+
+	return nil
+}
+
+// parseResellRule parses "<mode>:<value>", e.g. "markup:20".
+func parseResellRule(value string) (ResellRule, error) {
+	mode, amount, ok := strings.Cut(value, ":")
+	if !ok {
+		return ResellRule{}, fmt.Errorf("invalid resell rule %q, use fixed:<robux>, markup:<percent> or rap:<percent>", value)
+	}
+
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil || parsed <= 0 {
+		return ResellRule{}, fmt.Errorf("resell amount must be a positive number, got %q", amount)
+	}
+
+	switch mode = strings.TrimSpace(mode); mode {
+	case ResellFixed, ResellMarkup, ResellRAP:
+	default:
+		return ResellRule{}, fmt.Errorf("unknown resell mode %q, use fixed, markup or rap", mode)
+	}
+
+	return ResellRule{Mode: mode, Value: parsed}, nil
+}
+
+// ParseFile reads every line of the limiteds file and keeps going past bad lines,
+// so all of them can be reported at once. The error is only set when the file can't be read.
+func ParseFile(path string) ([]LimitedInfo, []*LineFormatError, error) {
 	// Open the file
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
 	// Use bufio.Scanner for efficient line-by-line reading
 	scanner := bufio.NewScanner(file)
 	var infos []LimitedInfo
+	var bad []*LineFormatError
 
+	line_number := 0
 	for scanner.Scan() {
 		// Read the line
 		line := scanner.Text()
+		line_number++
 
-		if len(line) == 0 {
+		if len(strings.TrimSpace(line)) == 0 {
 			log.Warn("Empty line detected in limiteds file, remove it or it can cause slower parsing times.")
 			continue
 		}
 
-		// Split the line into parts (id and price)
-		parts := strings.Split(line, ",")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid line format: %s", line)
-		}
-
-		price, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		info, err := ParseLine(line)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse price: %w", err)
+			bad = append(bad, &LineFormatError{Line: line_number, err: err.Error()})
+			continue
 		}
 
 		// Append parsed struct to slice
-		infos = append(infos, LimitedInfo{
-			Id:    strings.TrimSpace(parts[0]),
-			Price: price,
-		})
+		infos = append(infos, info)
 	}
 
 	// Check for scanner errors
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return nil, nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return infos, bad, nil
+}
+
+// FromFile reads the file contents line by line from the provided path
+// and parses each line into LimitedInfo. Each line should follow the syntax: id<int>, price<int>[, key=value...].
+func FromFile(path string) ([]LimitedInfo, error) {
+	infos, bad, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bad) > 0 {
+		return nil, bad[0]
 	}
 
 	return infos, nil
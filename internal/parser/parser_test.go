@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFileReportsEveryBadLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limiteds.txt")
+	content := "1234, 500\nabc, 500\n\n5678, 300, max_owned=2\n5678, 300, color=red\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, bad, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 2 || infos[0].Id != "1234" || infos[1].MaxOwned != 2 {
+		t.Errorf("unexpected entries %+v", infos)
+	}
+	if len(bad) != 2 || bad[0].Line != 2 || bad[1].Line != 5 {
+		t.Fatalf("unexpected bad lines %v", bad)
+	}
+
+	if _, err := FromFile(path); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("FromFile must fail on the first bad line, got %v", err)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	release := time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		line string
+		want LimitedInfo
+	}{
+		{
+			line: "1234, 500",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionBuy},
+		},
+		{
+			line: " 1234 ,500 , max_owned=2 ",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionBuy, MaxOwned: 2},
+		},
+		{
+			line: "1234, 500, kind=ugc",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindUGC, Type: TypeAsset, Action: ActionBuy},
+		},
+		{
+			line: "1234, 500, type=bundle",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindUGC, Type: TypeBundle, Action: ActionBuy},
+		},
+		{
+			line: "1234, 500, action=alert",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionAlert},
+		},
+		{
+			line: "1234, 500, action=alert-then-confirm",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionConfirm},
+		},
+		{
+			line: "1234, 500, release=2026-11-01T18:00:00Z",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionBuy, Release: release},
+		},
+		{
+			line: "1234, 500, resell=fixed:750, floor=600",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionBuy,
+				Resell: ResellRule{Mode: ResellFixed, Value: 750}, Floor: 600},
+		},
+		{
+			line: "1234, 500, resell=markup:12.5",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionBuy,
+				Resell: ResellRule{Mode: ResellMarkup, Value: 12.5}},
+		},
+		{
+			line: "1234, 500, resell=rap:95",
+			want: LimitedInfo{Id: "1234", Price: 500, Kind: KindClassic, Type: TypeAsset, Action: ActionBuy,
+				Resell: ResellRule{Mode: ResellRAP, Value: 95}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := ParseLine(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLineRejects(t *testing.T) {
+	tests := []struct {
+		line string
+		err  string
+	}{
+		{"1234", "invalid line format"},
+		{"abc, 500", "invalid limited id"},
+		{"1234, five", "failed to parse price"},
+		{"1234, 0", "price must be positive"},
+		{"1234, 500, max_owned", "use key=value"},
+		{"1234, 500, color=red", "unknown option"},
+		{"1234, 500, kind=rare", "unknown kind"},
+		{"1234, 500, type=gear", "unknown type"},
+		{"1234, 500, action=sell", "unknown action"},
+		{"1234, 500, release=tomorrow", "release must be an RFC 3339 time"},
+		{"1234, 500, max_owned=0", "max_owned must be a positive number"},
+		{"1234, 500, floor=-1", "floor must be a positive number"},
+		{"1234, 500, resell=fixed", "invalid resell rule"},
+		{"1234, 500, resell=fixed:0", "resell amount must be a positive number"},
+		{"1234, 500, resell=double:2", "unknown resell mode"},
+		{"1234, 500, type=bundle, kind=classic", "bundles are always bought as collectibles"},
+		{"1234, 500, type=bundle, max_owned=1", "max_owned is not supported for bundles"},
+		{"1234, 500, type=bundle, release=2026-11-01T18:00:00Z", "release is not supported for bundles"},
+		{"1234, 500, kind=ugc, resell=markup:20", "only supported for classic limiteds"},
+		{"1234, 500, kind=ugc, floor=600", "only supported for classic limiteds"},
+
+		// Our own listing would be the cheapest one and bought back
+		{"1234, 500, resell=fixed:500", "must be above the target"},
+		{"1234, 500, resell=fixed:400", "must be above the target"},
+		{"1234, 500, floor=500", "must be above the target"},
+		{"1234, 500, floor=450", "must be above the target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := ParseLine(tt.line)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
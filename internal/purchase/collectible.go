@@ -0,0 +1,133 @@
+package purchase
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type CollectiblePurchasePayload struct {
+	CollectibleItemID         string `json:"collectibleItemId"`
+	CollectibleItemInstanceID string `json:"collectibleItemInstanceId,omitempty"`
+	CollectibleProductID      string `json:"collectibleProductId"`
+	ExpectedCurrency          int    `json:"expectedCurrency"`
+	ExpectedPrice             int    `json:"expectedPrice"`
+	ExpectedPurchaserID       int    `json:"expectedPurchaserId"`
+	ExpectedPurchaserType     string `json:"expectedPurchaserType"`
+	ExpectedSellerID          int    `json:"expectedSellerId"`
+	ExpectedSellerType        string `json:"expectedSellerType"`
+	IdempotencyKey            string `json:"idempotencyKey"`
+}
+
+type CollectiblePurchaseResponse struct {
+	PurchaseResult string `json:"purchaseResult"`
+	Purchased      bool   `json:"purchased"`
+	Pending        bool   `json:"pending"`
+	ErrorMessage   string `json:"errorMessage"`
+}
+
+// Collectible identifies the UGC listing to buy. Without an InstanceID the copy is bought from
+// the creator (a new drop) instead of a reseller.
+type Collectible struct {
+	ItemID     string
+	ProductID  string
+	InstanceID string
+	SellerType string // User or Group, resellers are always users
+}
+
+// MakeCollectiblePurchase buys a UGC limited from a reseller or, for new drops, from its creator.
+// The answer is mapped onto a PurchaseResponse so callers handle both flows alike, it carries
+// no balance after the sale.
+func MakeCollectiblePurchase(csrf, cookie string, buyerID int, item Collectible, price, sellerID int) (*PurchaseResponse, error) {
+	start := time.Now()
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	sellerType := item.SellerType
+	if sellerType == "" {
+		sellerType = "User"
+	}
+
+	endpoint := "purchase-resale"
+	if item.InstanceID == "" {
+		endpoint = "purchase-item"
+	}
+
+	payload := CollectiblePurchasePayload{
+		CollectibleItemID:         item.ItemID,
+		CollectibleItemInstanceID: item.InstanceID,
+		CollectibleProductID:      item.ProductID,
+		ExpectedCurrency:          1,
+		ExpectedPrice:             price,
+		ExpectedPurchaserID:       buyerID,
+		ExpectedPurchaserType:     "User",
+		ExpectedSellerID:          sellerID,
+		ExpectedSellerType:        sellerType,
+		IdempotencyKey:            newIdempotencyKey(),
+	}
+
+	if err := json.NewEncoder(buf).Encode(payload); err != nil {
+		return nil, fmt.Errorf("error encoding purchase payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://apis.roblox.com/marketplace-sales/v1/item/%s/%s", item.ItemID, endpoint), buf)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.AddCookie(&http.Cookie{Name: ".ROBLOSECURITY", Value: cookie})
+	req.Header.Set("content-type", "application/json; charset=utf-8")
+	req.Header.Set("x-csrf-token", csrf)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing purchase request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	latency := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("purchase failed with status code: %d", resp.StatusCode)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var collectibleResponse CollectiblePurchaseResponse
+	if err := json.Unmarshal(respBody, &collectibleResponse); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response body: %w", err)
+	}
+
+	response := &PurchaseResponse{
+		Latency:       latency,
+		Purchased:     collectibleResponse.Purchased,
+		Reason:        collectibleResponse.PurchaseResult,
+		ErrorMsg:      collectibleResponse.ErrorMessage,
+		ExpectedPrice: price,
+		Price:         price,
+	}
+	if !response.Purchased && response.ErrorMsg == "" {
+		response.ErrorMsg = collectibleResponse.PurchaseResult
+	}
+
+	return response, nil
+}
+
+// newIdempotencyKey returns a random UUID, the marketplace rejects a repeated key.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
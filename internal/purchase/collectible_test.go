@@ -0,0 +1,220 @@
+package purchase
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+// redirect sends every request to the fixture server, whatever Roblox host it was for.
+type redirect struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return r.base.RoundTrip(req)
+}
+
+// fixture serves mux in place of the Roblox purchase endpoints.
+func fixture(t *testing.T, mux *http.ServeMux) {
+	t.Helper()
+
+	server := httptest.NewServer(mux)
+	target, _ := url.Parse(server.URL)
+
+	previous := httpClient.Transport
+	httpClient.Transport = redirect{target: target, base: http.DefaultTransport}
+
+	t.Cleanup(func() {
+		httpClient.Transport = previous
+		server.Close()
+	})
+}
+
+// decodePurchase checks the headers every purchase sends and decodes its body into v.
+func decodePurchase(t *testing.T, r *http.Request, v any) {
+	t.Helper()
+
+	if c, err := r.Cookie(".ROBLOSECURITY"); err != nil || c.Value != "cookie" {
+		t.Error("missing .ROBLOSECURITY cookie")
+	}
+	if token := r.Header.Get("X-Csrf-Token"); token != "token" {
+		t.Errorf("x-csrf-token = %q, want token", token)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("invalid purchase body %s: %v", body, err)
+	}
+}
+
+var uuid = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestMakePurchaseClassic(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/purchases/products/555", func(w http.ResponseWriter, r *http.Request) {
+		var payload PurchasePayload
+		decodePurchase(t, r, &payload)
+
+		want := PurchasePayload{ExpectedCurrency: 1, ExpectedSellerID: 77, ExpectedPrice: 420, UserAssetID: 9001}
+		if payload != want {
+			t.Errorf("payload = %+v, want %+v", payload, want)
+		}
+
+		fmt.Fprint(w, `{"purchased": true, "reason": "Success", "productId": 555, "statusCode": 200,
+			"balanceAfterSale": 1580, "expectedPrice": 420, "price": 420, "assetId": 1001}`)
+	})
+	fixture(t, mux)
+
+	got, err := MakePurchase("token", "cookie", 555, 420, 77, 9001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Purchased || got.Reason != "Success" || got.BalanceAfterSale != 1580 || got.Price != 420 || got.AssetId != 1001 {
+		t.Errorf("unexpected response %+v", got)
+	}
+	if got.Latency <= 0 {
+		t.Error("latency was not measured")
+	}
+}
+
+func TestMakePurchaseClassicRejected(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/purchases/products/556", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"purchased": false, "reason": "InsufficientFunds", "errorMsg": "You need more Robux", "shortfallPrice": 20}`)
+	})
+	fixture(t, mux)
+
+	got, err := MakePurchase("token", "cookie", 556, 420, 77, 9001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Purchased || got.Reason != "InsufficientFunds" || got.ShortfallPrice != 20 {
+		t.Errorf("unexpected response %+v", got)
+	}
+}
+
+func TestMakePurchaseHTTPError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/purchases/products/557", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	fixture(t, mux)
+
+	if _, err := MakePurchase("token", "cookie", 557, 420, 77, 9001); err == nil {
+		t.Error("a 403 must be an error")
+	}
+}
+
+func TestMakeCollectiblePurchase(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     Collectible
+		endpoint string
+		want     CollectiblePurchasePayload
+		response string
+		result   PurchaseResponse
+	}{
+		{
+			name:     "resale",
+			item:     Collectible{ItemID: "item-2001", ProductID: "resale-product-2001", InstanceID: "instance-7"},
+			endpoint: "/marketplace-sales/v1/item/item-2001/purchase-resale",
+			want: CollectiblePurchasePayload{
+				CollectibleItemID:         "item-2001",
+				CollectibleItemInstanceID: "instance-7",
+				CollectibleProductID:      "resale-product-2001",
+				ExpectedCurrency:          1,
+				ExpectedPrice:             350,
+				ExpectedPurchaserID:       42,
+				ExpectedPurchaserType:     "User",
+				ExpectedSellerID:          88,
+				ExpectedSellerType:        "User",
+			},
+			response: `{"purchaseResult": "Purchase transaction success.", "purchased": true, "pending": false, "errorMessage": ""}`,
+			result:   PurchaseResponse{Purchased: true, Reason: "Purchase transaction success.", ExpectedPrice: 350, Price: 350},
+		},
+		{
+			name:     "new drop from a group",
+			item:     Collectible{ItemID: "item-2004", ProductID: "product-2004", SellerType: "Group"},
+			endpoint: "/marketplace-sales/v1/item/item-2004/purchase-item",
+			want: CollectiblePurchasePayload{
+				CollectibleItemID:     "item-2004",
+				CollectibleProductID:  "product-2004",
+				ExpectedCurrency:      1,
+				ExpectedPrice:         350,
+				ExpectedPurchaserID:   42,
+				ExpectedPurchaserType: "User",
+				ExpectedSellerID:      88,
+				ExpectedSellerType:    "Group",
+			},
+			response: `{"purchaseResult": "Purchase transaction success.", "purchased": true}`,
+			result:   PurchaseResponse{Purchased: true, Reason: "Purchase transaction success.", ExpectedPrice: 350, Price: 350},
+		},
+		{
+			name:     "resale rejected",
+			item:     Collectible{ItemID: "item-2005", ProductID: "resale-product-2005", InstanceID: "instance-9"},
+			endpoint: "/marketplace-sales/v1/item/item-2005/purchase-resale",
+			want: CollectiblePurchasePayload{
+				CollectibleItemID:         "item-2005",
+				CollectibleItemInstanceID: "instance-9",
+				CollectibleProductID:      "resale-product-2005",
+				ExpectedCurrency:          1,
+				ExpectedPrice:             350,
+				ExpectedPurchaserID:       42,
+				ExpectedPurchaserType:     "User",
+				ExpectedSellerID:          88,
+				ExpectedSellerType:        "User",
+			},
+			response: `{"purchaseResult": "Price mismatch", "purchased": false}`,
+			result:   PurchaseResponse{Purchased: false, Reason: "Price mismatch", ErrorMsg: "Price mismatch", ExpectedPrice: 350, Price: 350},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST "+tt.endpoint, func(w http.ResponseWriter, r *http.Request) {
+				var payload CollectiblePurchasePayload
+				decodePurchase(t, r, &payload)
+
+				if !uuid.MatchString(payload.IdempotencyKey) {
+					t.Errorf("idempotency key %q is not a v4 UUID", payload.IdempotencyKey)
+				}
+				payload.IdempotencyKey = ""
+				if payload != tt.want {
+					t.Errorf("payload = %+v, want %+v", payload, tt.want)
+				}
+
+				fmt.Fprint(w, tt.response)
+			})
+			fixture(t, mux)
+
+			got, err := MakeCollectiblePurchase("token", "cookie", 42, tt.item, 350, 88)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Latency <= 0 {
+				t.Error("latency was not measured")
+			}
+			got.Latency = 0
+			if *got != tt.result {
+				t.Errorf("got %+v, want %+v", *got, tt.result)
+			}
+		})
+	}
+}
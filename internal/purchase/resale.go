@@ -0,0 +1,44 @@
+package purchase
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/goccy/go-json"
+)
+
+type ResalePayload struct {
+	Price int `json:"price"`
+}
+
+// SetResalePrice puts a copy we own on sale for price, a price of 0 takes it off sale.
+func SetResalePrice(csrf, cookie, assetID string, userAssetID, price int) error {
+	body, err := json.Marshal(ResalePayload{Price: price})
+	if err != nil {
+		return fmt.Errorf("error encoding resale payload: %w", err)
+	}
+
+	req, err := http.NewRequest("PATCH", fmt.Sprintf("https://economy.roblox.com/v1/assets/%s/resellable-copies/%d", assetID, userAssetID), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.AddCookie(&http.Cookie{Name: ".ROBLOSECURITY", Value: cookie})
+	req.Header.Set("content-type", "application/json; charset=utf-8")
+	req.Header.Set("x-csrf-token", csrf)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error executing resale request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("resale failed with status code %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
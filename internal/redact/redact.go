@@ -0,0 +1,136 @@
+package redact
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every secret found in output.
+const Mask = "[REDACTED]"
+
+// Values shorter than this are not registered, masking them would mangle unrelated output.
+const minSecretLength = 8
+
+// Known secret shapes, masked even when the value was never registered
+// (e.g. a cookie pasted into an error by the Roblox API or a webhook URL in a net/http error).
+var patterns = []struct {
+	re      *regexp.Regexp
+	replace string
+}{
+	// .ROBLOSECURITY values always start with this warning
+	{regexp.MustCompile(`_\|WARNING:-DO-NOT-SHARE-THIS\.[^\s"';,]*`), Mask},
+	{regexp.MustCompile(`(\.ROBLOSECURITY["']?\s*[=:]\s*["']?)[^\s"';,]+`), "${1}" + Mask},
+	// Also matches header maps, e.g. X-Csrf-Token:[token] or "X-Csrf-Token":["token"]
+	{regexp.MustCompile(`(?i)(x-csrf-token["']?\s*[=:]?\s*\[?["']?)[A-Za-z0-9+/=_-]{6,}`), "${1}" + Mask},
+	{regexp.MustCompile(`(discord(?:app)?\.com/api/webhooks/\d+/)[\w-]+`), "${1}" + Mask},
+	{regexp.MustCompile(`(hooks\.slack\.com/services/)[\w/]+`), "${1}" + Mask},
+	{regexp.MustCompile(`(api\.telegram\.org/bot)[^/\s"]+`), "${1}" + Mask},
+}
+
+var (
+	secrets  = map[string]struct{}{}
+	replacer = strings.NewReplacer()
+	mu       sync.RWMutex
+)
+
+// Register adds values that must never show up in output, e.g. the cookie,
+// a CSRF token or a webhook URL. Empty and very short values are ignored.
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	changed := false
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) < minSecretLength {
+			continue
+		}
+		if _, ok := secrets[value]; !ok {
+			secrets[value] = struct{}{}
+			changed = true
+		}
+	}
+
+	if changed {
+		replacer = buildReplacer()
+	}
+}
+
+// buildReplacer masks longer secrets first, so a webhook URL is not half replaced by its token.
+func buildReplacer() *strings.Replacer {
+	values := make([]string, 0, len(secrets))
+	for value := range secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	pairs := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		pairs = append(pairs, value, Mask)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// String masks registered secrets and known secret shapes in s.
+func String(s string) string {
+	mu.RLock()
+	r := replacer
+	mu.RUnlock()
+
+	s = r.Replace(s)
+	for _, pattern := range patterns {
+		s = pattern.re.ReplaceAllString(s, pattern.replace)
+	}
+	return s
+}
+
+// Value formats v like %+v with secrets masked, for debug dumps of whole structs.
+func Value(v interface{}) string {
+	return String(fmt.Sprintf("%+v", v))
+}
+
+// redactedError keeps the original error reachable through errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Error returns err with secrets masked from its message, nil stays nil.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*redactedError); ok {
+		return err
+	}
+
+	return &redactedError{msg: String(err.Error()), err: err}
+}
+
+type writer struct {
+	w io.Writer
+}
+
+// Writer wraps w so everything written through it is masked.
+// Loggers write one entry per call, so secrets are never split across writes.
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+func (rw *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	// Report the length the caller gave us, masking changes the size
+	return len(p), nil
+}
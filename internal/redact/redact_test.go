@@ -0,0 +1,161 @@
+package redact_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sniper/internal/logging"
+	"sniper/internal/redact"
+	"strings"
+	"testing"
+)
+
+// Registered secrets, as the sniper registers the cookie, CSRF token and webhook URL on start.
+const (
+	cookie  = "_|WARNING:-DO-NOT-SHARE-THIS.--Sharing-this-will-allow-someone-to-log-in-as-you-and-to-steal-your-ROBUX-and-items.|_REGISTEREDCOOKIE0123456789"
+	token   = "RegisteredCsrfToken42"
+	webhook = "https://discord.com/api/webhooks/111111111111/RegisteredWebhookToken_abc-DEF"
+)
+
+func init() {
+	redact.Register(cookie, token, webhook)
+}
+
+// assertClean fails when any of the secrets is still in out.
+func assertClean(t *testing.T, out string, secrets ...string) {
+	t.Helper()
+
+	for _, secret := range append([]string{cookie, token, webhook}, secrets...) {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, redact.Mask) {
+		t.Errorf("output has no %s marker:\n%s", redact.Mask, out)
+	}
+}
+
+func TestString(t *testing.T) {
+	out := redact.String(fmt.Sprintf("cookie=%s token=%s posting to %s failed", cookie, token, webhook))
+	assertClean(t, out)
+
+	if !strings.Contains(out, "posting to") {
+		t.Errorf("text around the secrets was lost: %s", out)
+	}
+}
+
+func TestStringLeavesShortValues(t *testing.T) {
+	redact.Register("", "short")
+	if out := redact.String("a short message"); out != "a short message" {
+		t.Errorf("short values must not be masked, got %q", out)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		secret string // Unregistered secret that must be masked
+	}{
+		{
+			name:   "cookie warning prefix",
+			input:  "body: _|WARNING:-DO-NOT-SHARE-THIS.--Sharing-this-will-allow-someone-to-log-in-as-you.|_UNREGISTEREDCOOKIE987",
+			secret: "UNREGISTEREDCOOKIE987",
+		},
+		{
+			name:   "cookie assignment",
+			input:  "Cookie: .ROBLOSECURITY=UnregisteredCookieValue987; path=/",
+			secret: "UnregisteredCookieValue987",
+		},
+		{
+			name:   "cookie in json",
+			input:  `{".ROBLOSECURITY": "UnregisteredCookieValue654"}`,
+			secret: "UnregisteredCookieValue654",
+		},
+		{
+			name:   "csrf header map",
+			input:  "headers: map[Content-Type:[application/json] X-Csrf-Token:[UnregisteredToken77]]",
+			secret: "UnregisteredToken77",
+		},
+		{
+			name:   "csrf header json",
+			input:  `{"X-Csrf-Token":["UnregisteredToken88"]}`,
+			secret: "UnregisteredToken88",
+		},
+		{
+			name:   "csrf header line",
+			input:  "x-csrf-token: UnregisteredToken99",
+			secret: "UnregisteredToken99",
+		},
+		{
+			name:   "discord webhook",
+			input:  `Post "https://discord.com/api/webhooks/222222222222/Unregistered-Discord_Token": dial tcp: timeout`,
+			secret: "Unregistered-Discord_Token",
+		},
+		{
+			name:   "discordapp webhook",
+			input:  "https://discordapp.com/api/webhooks/333333333333/UnregisteredDiscordAppToken",
+			secret: "UnregisteredDiscordAppToken",
+		},
+		{
+			name:   "slack webhook",
+			input:  "https://hooks.slack.com/services/T0UNREG/B0UNREG/UnregisteredSlackSecret",
+			secret: "T0UNREG/B0UNREG/UnregisteredSlackSecret",
+		},
+		{
+			name:   "telegram bot",
+			input:  "https://api.telegram.org/bot123456789:Unregistered-Telegram_Token/sendMessage",
+			secret: "123456789:Unregistered-Telegram_Token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertClean(t, redact.String(tt.input), tt.secret)
+		})
+	}
+}
+
+var errUnauthorized = errors.New("unauthorized")
+
+func TestError(t *testing.T) {
+	if redact.Error(nil) != nil {
+		t.Fatal("Error(nil) must stay nil")
+	}
+
+	err := fmt.Errorf("request with cookie %s and X-Csrf-Token:[UnregisteredToken55] to %s: %w", cookie, webhook, errUnauthorized)
+	redacted := redact.Error(err)
+
+	assertClean(t, redacted.Error(), "UnregisteredToken55")
+	if !errors.Is(redacted, errUnauthorized) {
+		t.Error("errors.Is must see the wrapped error through Unwrap")
+	}
+	if !errors.Is(fmt.Errorf("outer: %w", redacted), errUnauthorized) {
+		t.Error("errors.Is must see the wrapped error when the redacted error is wrapped again")
+	}
+	if redact.Error(redacted) != redacted {
+		t.Error("an error that is already redacted must be returned as is")
+	}
+}
+
+func TestWriterBehindLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logging.SetOutput(&buf)
+	defer logging.SetOutput(os.Stderr)
+
+	logger := logging.For("redact-test")
+	logger.Info("Login failed", "Cookie", cookie, "Token", token)
+	logger.Error("Webhook failed", "Error", redact.Error(fmt.Errorf("post %s: %w", webhook, errUnauthorized)))
+	logger.Warn("Unregistered secrets",
+		"Header", "map[X-Csrf-Token:[UnregisteredToken66]]",
+		"Slack", "https://hooks.slack.com/services/T0LOG/B0LOG/UnregisteredLogSecret",
+		"Cookie", ".ROBLOSECURITY=UnregisteredLogCookie321",
+	)
+
+	out := buf.String()
+	assertClean(t, out, "UnregisteredToken66", "T0LOG/B0LOG/UnregisteredLogSecret", "UnregisteredLogCookie321")
+	if strings.Count(out, "\n") != 3 {
+		t.Errorf("expected 3 log lines, got:\n%s", out)
+	}
+}
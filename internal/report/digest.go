@@ -0,0 +1,330 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sniper/internal/config"
+	"sniper/internal/eventlog"
+	"sniper/internal/logging"
+	"sniper/internal/metrics"
+	"sniper/internal/webhook"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var logger = logging.For("digest")
+
+const (
+	DefaultDirectory = "reports"
+	topNearMisses    = 5
+)
+
+type ItemSummary struct {
+	LimitedID   string        `json:"limited_id"`
+	Polls       int64         `json:"polls"`
+	LastLatency time.Duration `json:"last_latency"`
+	Errors      int           `json:"errors"`
+	NearMisses  int           `json:"near_misses"`
+	Purchases   int           `json:"purchases"`
+}
+
+type NearMissSummary struct {
+	LimitedID string `json:"limited_id"`
+	Count     int    `json:"count"`
+	Target    int    `json:"target"`
+	Closest   int    `json:"closest"` // Lowest price seen above the target
+}
+
+type Digest struct {
+	Start         time.Time          `json:"start"`
+	End           time.Time          `json:"end"`
+	Polls         int64              `json:"polls"`
+	Items         []ItemSummary      `json:"items"`
+	Errors        map[string]int     `json:"errors"`
+	ErrorCount    int                `json:"error_count"`
+	ErrorRate     float64            `json:"error_rate"` // Errors per 100 polls
+	ErrorRates    map[string]float64 `json:"error_rates"`
+	Purchases     []eventlog.Event   `json:"purchases"`
+	TotalSpent    int                `json:"total_spent"`
+	ValueCaptured int                `json:"value_captured"` // Sum of RAP minus price paid
+	Sales         []eventlog.Event   `json:"sales"`
+	TotalProceeds int                `json:"total_proceeds"` // Sales after the marketplace fee
+	NearMisses    []NearMissSummary  `json:"near_misses"`
+	Balance       int                `json:"balance"`
+	BalanceKnown  bool               `json:"balance_known"`
+}
+
+// Balance reports the current Robux balance for the digest, when nil the
+// balance left after the latest purchase of the period is used.
+var Balance func() (int, bool)
+
+// Poll counters at the time of the previous digest, used to report per-period polls.
+var lastPolls = map[string]int64{}
+
+// Build collects the digest for the period from the event log and the worker counters. The period
+// includes start but not end, an event at the boundary belongs to the next digest only.
+func Build(start, end time.Time) (Digest, error) {
+	digest := Digest{
+		Start:      start,
+		End:        end,
+		Errors:     map[string]int{},
+		ErrorRates: map[string]float64{},
+	}
+
+	events, err := eventlog.ReadSince(start)
+	if err != nil {
+		return digest, err
+	}
+
+	items := map[string]*ItemSummary{}
+	item := func(id string) *ItemSummary {
+		if _, ok := items[id]; !ok {
+			items[id] = &ItemSummary{LimitedID: id}
+		}
+		return items[id]
+	}
+
+	metrics.PollCounts.Range(func(key, value any) bool {
+		id := key.(string)
+		total := value.(*atomic.Int64).Load()
+		summary := item(id)
+		summary.Polls = total - lastPolls[id]
+		lastPolls[id] = total
+		digest.Polls += summary.Polls
+
+		if check, ok := metrics.IterationChecks.Load(id); ok {
+			summary.LastLatency = check.(metrics.LastCheck).TimeTaken
+		}
+		return true
+	})
+
+	nearMisses := map[string]*NearMissSummary{}
+	for _, event := range events {
+		if !event.Time.Before(end) {
+			continue
+		}
+
+		switch event.Type {
+		case eventlog.TypeError:
+			item(event.LimitedID).Errors++
+			digest.Errors[event.ErrorType]++
+			digest.ErrorCount++
+		case eventlog.TypePurchaseFailure:
+			item(event.LimitedID).Errors++
+			digest.Errors[eventlog.TypePurchaseFailure]++
+			digest.ErrorCount++
+		case eventlog.TypePurchase:
+			item(event.LimitedID).Purchases++
+			digest.Purchases = append(digest.Purchases, event)
+			digest.TotalSpent += event.Price
+			if event.RAP > 0 {
+				digest.ValueCaptured += event.RAP - event.Price
+			}
+			digest.Balance = event.Balance
+			digest.BalanceKnown = true
+		case eventlog.TypeSale:
+			digest.Sales = append(digest.Sales, event)
+			digest.TotalProceeds += event.Proceeds
+		case eventlog.TypeNearMiss:
+			item(event.LimitedID).NearMisses++
+			miss, ok := nearMisses[event.LimitedID]
+			if !ok {
+				miss = &NearMissSummary{LimitedID: event.LimitedID, Target: event.Target, Closest: event.Price}
+				nearMisses[event.LimitedID] = miss
+			}
+			miss.Count++
+			if event.Price < miss.Closest {
+				miss.Closest = event.Price
+			}
+		}
+	}
+
+	if digest.Polls > 0 {
+		digest.ErrorRate = float64(digest.ErrorCount) / float64(digest.Polls) * 100
+		for errorType, count := range digest.Errors {
+			digest.ErrorRates[errorType] = float64(count) / float64(digest.Polls) * 100
+		}
+	}
+
+	for _, summary := range items {
+		digest.Items = append(digest.Items, *summary)
+	}
+	sort.Slice(digest.Items, func(i, j int) bool {
+		return digest.Items[i].LimitedID < digest.Items[j].LimitedID
+	})
+
+	for _, miss := range nearMisses {
+		digest.NearMisses = append(digest.NearMisses, *miss)
+	}
+	sort.Slice(digest.NearMisses, func(i, j int) bool {
+		if digest.NearMisses[i].Count != digest.NearMisses[j].Count {
+			return digest.NearMisses[i].Count > digest.NearMisses[j].Count
+		}
+		return digest.NearMisses[i].LimitedID < digest.NearMisses[j].LimitedID
+	})
+
+	if Balance != nil {
+		digest.Balance, digest.BalanceKnown = Balance()
+	}
+
+	return digest, nil
+}
+
+// TopNearMisses returns the items that came closest to their target the most often.
+func (d Digest) TopNearMisses() []NearMissSummary {
+	if len(d.NearMisses) > topNearMisses {
+		return d.NearMisses[:topNearMisses]
+	}
+	return d.NearMisses
+}
+
+// Markdown renders the full digest, written to disk next to the JSON version.
+func (d Digest) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Sniper Digest\n\n%s to %s\n\n", d.Start.Format(time.RFC3339), d.End.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "## Polls\n\n| Item | Polls | Errors | Near Misses | Purchases | Last Latency |\n|---|---|---|---|---|---|\n")
+	for _, item := range d.Items {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %v |\n", item.LimitedID, item.Polls, item.Errors, item.NearMisses, item.Purchases, item.LastLatency)
+	}
+	fmt.Fprintf(&b, "\nTotal polls: %d\n\n", d.Polls)
+
+	fmt.Fprintf(&b, "## Errors\n\nTotal: %d (%.2f per 100 polls)\n\n", d.ErrorCount, d.ErrorRate)
+	errorTypes := make([]string, 0, len(d.Errors))
+	for errorType := range d.Errors {
+		errorTypes = append(errorTypes, errorType)
+	}
+	sort.Strings(errorTypes)
+	for _, errorType := range errorTypes {
+		fmt.Fprintf(&b, "- %s: %d (%.2f per 100 polls)\n", errorType, d.Errors[errorType], d.ErrorRates[errorType])
+	}
+
+	fmt.Fprintf(&b, "\n## Purchases\n\nTotal spent: R$ %d, estimated value captured: R$ %d\n\n", d.TotalSpent, d.ValueCaptured)
+	for _, purchase := range d.Purchases {
+		fmt.Fprintf(&b, "- %s: %s bought for R$ %d (target R$ %d, RAP R$ %d)\n", purchase.Time.Format(time.RFC3339), purchase.LimitedID, purchase.Price, purchase.Target, purchase.RAP)
+	}
+
+	if len(d.Sales) > 0 {
+		fmt.Fprintf(&b, "\n## Sales\n\nTotal proceeds: R$ %d\n\n", d.TotalProceeds)
+		for _, sale := range d.Sales {
+			fmt.Fprintf(&b, "- %s: %s sold for R$ %d (R$ %d after fees)\n", sale.Time.Format(time.RFC3339), sale.LimitedID, sale.Price, sale.Proceeds)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Near Misses\n\n")
+	for _, miss := range d.NearMisses {
+		fmt.Fprintf(&b, "- %s: %d listings, closest R$ %d for a target of R$ %d\n", miss.LimitedID, miss.Count, miss.Closest, miss.Target)
+	}
+
+	if d.BalanceKnown {
+		fmt.Fprintf(&b, "\n## Balance\n\nR$ %d\n", d.Balance)
+	}
+
+	return b.String()
+}
+
+// Write saves the digest as markdown and JSON into the reports directory.
+func (d Digest) Write(directory string) error {
+	if directory == "" {
+		directory = DefaultDirectory
+	}
+
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	name := filepath.Join(directory, "digest-"+d.End.Format("20060102-1504"))
+
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest: %w", err)
+	}
+
+	if err := os.WriteFile(name+".json", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write digest: %w", err)
+	}
+
+	if err := os.WriteFile(name+".md", []byte(d.Markdown()), 0o644); err != nil {
+		return fmt.Errorf("failed to write digest: %w", err)
+	}
+
+	return nil
+}
+
+// nextRun returns when the next digest is due, hourly and daily digests line up with the clock.
+func nextRun(interval string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(interval) {
+	case "hourly":
+		return now.Truncate(time.Hour).Add(time.Hour), nil
+	case "daily":
+		year, month, day := now.Date()
+		return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	every, err := time.ParseDuration(interval)
+	if err != nil || every <= 0 {
+		return time.Time{}, fmt.Errorf("invalid digest interval %q, use hourly, daily or a duration", interval)
+	}
+
+	return now.Add(every), nil
+}
+
+// Run sends and writes a digest every interval, it blocks so call it in its own goroutine.
+func Run(cfg config.DigestConfig) {
+	start := time.Now()
+
+	for {
+		next, err := nextRun(cfg.Interval, time.Now())
+		if err != nil {
+			logger.Error("Digest disabled", "Error", err)
+			return
+		}
+
+		time.Sleep(time.Until(next))
+
+		end := time.Now()
+		digest, err := Build(start, end)
+		if err != nil {
+			logger.Error("Could not build digest", "Error", err)
+			continue
+		}
+		start = end
+
+		if err := digest.Write(cfg.Directory); err != nil {
+			logger.Error("Could not write digest", "Error", err)
+		}
+
+		description, err := webhook.Render(webhook.EventDigest, digest)
+		if err != nil {
+			logger.Error("Could not render webhook template", "Event", webhook.EventDigest, "Error", err)
+			continue
+		}
+
+		webhook.Notify(webhook.Message{
+			Event:       webhook.EventDigest,
+			Title:       "Sniper Digest",
+			Description: description,
+			Color:       0x3ba55d,
+		})
+
+		logger.Info("Digest sent", "Polls", digest.Polls, "Purchases", len(digest.Purchases), "Errors", digest.ErrorCount)
+	}
+}
+
+func init() {
+	config.AddValidator(func(cfg *config.ConfigStruct) []config.FieldError {
+		if cfg.Digest.Interval == "" {
+			return nil
+		}
+		if _, err := nextRun(cfg.Digest.Interval, time.Now()); err != nil {
+			return []config.FieldError{{Path: "digest.interval", Message: err.Error()}}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,235 @@
+package report
+
+import (
+	"math"
+	"path/filepath"
+	"sniper/internal/eventlog"
+	"sniper/internal/metrics"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// setup points the event log at a fresh file with the given events and sets the poll counters.
+func setup(t *testing.T, polls map[string]int64, events ...eventlog.Event) {
+	t.Helper()
+
+	if err := eventlog.Init(filepath.Join(t.TempDir(), "events.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics.PollCounts.Range(func(key, _ any) bool {
+		metrics.PollCounts.Delete(key)
+		return true
+	})
+	lastPolls = map[string]int64{}
+	setPolls(polls)
+
+	for _, event := range events {
+		eventlog.Record(event)
+	}
+}
+
+func setPolls(polls map[string]int64) {
+	for id, count := range polls {
+		counter, _ := metrics.PollCounts.LoadOrStore(id, new(atomic.Int64))
+		counter.(*atomic.Int64).Store(count)
+	}
+}
+
+// at is an event of the given type minutes into the period.
+func at(minutes int, eventType, limitedID string) eventlog.Event {
+	return eventlog.Event{Time: start.Add(time.Duration(minutes) * time.Minute), Type: eventType, LimitedID: limitedID}
+}
+
+func nearMiss(minutes int, limitedID string, price, target int) eventlog.Event {
+	e := at(minutes, eventlog.TypeNearMiss, limitedID)
+	e.Price, e.Target = price, target
+	return e
+}
+
+func failure(minutes int, limitedID, errorType string) eventlog.Event {
+	e := at(minutes, eventlog.TypeError, limitedID)
+	e.ErrorType = errorType
+	return e
+}
+
+func TestBuildRanksNearMisses(t *testing.T) {
+	setup(t, nil,
+		nearMiss(1, "3333", 540, 500),
+		nearMiss(2, "1111", 120, 100),
+		nearMiss(3, "3333", 510, 500),
+		nearMiss(4, "1111", 105, 100),
+		nearMiss(5, "2222", 90, 80),
+		nearMiss(6, "3333", 525, 500),
+		nearMiss(7, "1111", 110, 100),
+		nearMiss(8, "4444", 60, 50),
+		nearMiss(9, "5555", 60, 50),
+		nearMiss(10, "6666", 60, 50),
+		nearMiss(11, "7777", 60, 50),
+	)
+
+	digest, err := Build(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Most near misses first, ties by id
+	want := []NearMissSummary{
+		{LimitedID: "1111", Count: 3, Target: 100, Closest: 105},
+		{LimitedID: "3333", Count: 3, Target: 500, Closest: 510},
+		{LimitedID: "2222", Count: 1, Target: 80, Closest: 90},
+		{LimitedID: "4444", Count: 1, Target: 50, Closest: 60},
+		{LimitedID: "5555", Count: 1, Target: 50, Closest: 60},
+	}
+	top := digest.TopNearMisses()
+	if len(top) != len(want) {
+		t.Fatalf("got top near misses %+v, want %+v", top, want)
+	}
+	for i := range want {
+		if top[i] != want[i] {
+			t.Errorf("rank %d: got %+v, want %+v", i+1, top[i], want[i])
+		}
+	}
+	if len(digest.NearMisses) != 7 {
+		t.Errorf("the full digest must keep every item, got %d", len(digest.NearMisses))
+	}
+}
+
+func TestBuildErrorRates(t *testing.T) {
+	setup(t, map[string]int64{"1111": 150, "2222": 50},
+		failure(1, "1111", eventlog.ErrorScrape),
+		failure(2, "1111", eventlog.ErrorScrape),
+		failure(3, "2222", eventlog.ErrorPurchase),
+		failure(4, "2222", eventlog.ErrorThumbnail),
+		at(5, eventlog.TypePurchaseFailure, "1111"),
+		at(6, eventlog.TypeNearMiss, "1111"), // Not an error
+	)
+
+	digest, err := Build(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest.Polls != 200 || digest.ErrorCount != 5 {
+		t.Fatalf("got %d polls and %d errors, want 200 and 5", digest.Polls, digest.ErrorCount)
+	}
+	if math.Abs(digest.ErrorRate-2.5) > 1e-9 {
+		t.Errorf("error rate = %v per 100 polls, want 2.5", digest.ErrorRate)
+	}
+
+	want := map[string]float64{
+		eventlog.ErrorScrape:         1,
+		eventlog.ErrorPurchase:       0.5,
+		eventlog.ErrorThumbnail:      0.5,
+		eventlog.TypePurchaseFailure: 0.5,
+	}
+	if len(digest.ErrorRates) != len(want) {
+		t.Errorf("got error buckets %v, want %v", digest.ErrorRates, want)
+	}
+	for bucket, rate := range want {
+		if math.Abs(digest.ErrorRates[bucket]-rate) > 1e-9 {
+			t.Errorf("%s: rate %v, want %v", bucket, digest.ErrorRates[bucket], rate)
+		}
+	}
+
+	items := map[string]ItemSummary{}
+	for _, item := range digest.Items {
+		items[item.LimitedID] = item
+	}
+	if items["1111"].Errors != 3 || items["1111"].Polls != 150 || items["2222"].Errors != 2 || items["2222"].Polls != 50 {
+		t.Errorf("unexpected item summaries %+v", digest.Items)
+	}
+
+	// The next digest only counts the polls since this one
+	setPolls(map[string]int64{"1111": 160, "2222": 50})
+	next, err := Build(start.Add(time.Hour), start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Polls != 10 || next.ErrorCount != 0 || next.ErrorRate != 0 {
+		t.Errorf("next period: %d polls, %d errors, rate %v, want 10, 0, 0", next.Polls, next.ErrorCount, next.ErrorRate)
+	}
+}
+
+func TestBuildPurchasesAndSales(t *testing.T) {
+	bought := func(minutes, price, rap, balance int) eventlog.Event {
+		e := at(minutes, eventlog.TypePurchase, "1111")
+		e.Price, e.RAP, e.Balance = price, rap, balance
+		return e
+	}
+	sold := func(minutes, price, proceeds int) eventlog.Event {
+		e := at(minutes, eventlog.TypeSale, "1111")
+		e.Price, e.Proceeds = price, proceeds
+		return e
+	}
+
+	setup(t, nil,
+		bought(1, 400, 600, 1600),
+		bought(2, 300, 0, 1300), // No RAP known, no value counted
+		sold(3, 1000, 700),
+		sold(4, 500, 350),
+	)
+
+	digest, err := Build(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(digest.Purchases) != 2 || digest.TotalSpent != 700 || digest.ValueCaptured != 200 {
+		t.Errorf("got %d purchases, spent R$ %d, captured R$ %d, want 2, 700, 200", len(digest.Purchases), digest.TotalSpent, digest.ValueCaptured)
+	}
+	if !digest.BalanceKnown || digest.Balance != 1300 {
+		t.Errorf("balance = %d (known %v), want the one after the last purchase, 1300", digest.Balance, digest.BalanceKnown)
+	}
+	if len(digest.Sales) != 2 || digest.TotalProceeds != 1050 {
+		t.Errorf("got %d sales with R$ %d proceeds, want 2 and 1050", len(digest.Sales), digest.TotalProceeds)
+	}
+}
+
+func TestBuildPeriodBoundary(t *testing.T) {
+	boundary := start.Add(time.Hour)
+	setup(t, nil,
+		failure(0, "1111", eventlog.ErrorScrape),  // First instant of the first period
+		failure(60, "1111", eventlog.ErrorScrape), // Exactly at the boundary
+		failure(119, "1111", eventlog.ErrorScrape),
+	)
+
+	first, err := Build(start, boundary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Build(boundary, boundary.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.ErrorCount != 1 || second.ErrorCount != 2 {
+		t.Errorf("got %d and %d errors, want 1 and 2: the boundary event belongs to the second digest only", first.ErrorCount, second.ErrorCount)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 34, 56, 0, time.UTC)
+
+	tests := []struct {
+		interval string
+		want     time.Time
+		err      bool
+	}{
+		{"hourly", time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), false},
+		{"Daily", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), false},
+		{"30m", now.Add(30 * time.Minute), false},
+		{"0s", time.Time{}, true},
+		{"weekly", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := nextRun(tt.interval, now)
+		if (err != nil) != tt.err || !got.Equal(tt.want) {
+			t.Errorf("nextRun(%q) = %v, %v, want %v (error %v)", tt.interval, got, err, tt.want, tt.err)
+		}
+	}
+}
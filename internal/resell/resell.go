@@ -0,0 +1,304 @@
+package resell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sniper/internal/config"
+	"sniper/internal/csrf"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/purchase"
+	"sniper/internal/rules"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var logger = logging.For("resell")
+
+// A copy the inventory API still doesn't show after this long is given up on.
+const giveUpAfter = 24 * time.Hour
+
+// Job is a sniped copy waiting to be put on sale, kept on disk because hold periods outlast restarts.
+type Job struct {
+	LimitedID   string            `json:"limited_id"`
+	ItemName    string            `json:"item_name,omitempty"`
+	UserAssetID int               `json:"user_asset_id"`
+	Paid        int               `json:"paid"`
+	Rule        parser.ResellRule `json:"rule"`
+	Attempt     string            `json:"attempt,omitempty"`
+	BoughtAt    time.Time         `json:"bought_at"`
+	Tries       int               `json:"tries"`
+}
+
+var (
+	itemRules   = map[string]parser.ResellRule{}
+	jobs        []*Job
+	statePath   string
+	maxAttempts int
+	mu          sync.Mutex
+	wake        = make(chan struct{}, 1)
+)
+
+// Start lists sniped copies of items with a resell rule once their hold period is over.
+// Jobs left over from a previous run are picked up again.
+func Start(cfg config.ResellConfig, limiteds []parser.LimitedInfo) error {
+	mu.Lock()
+	for _, limited := range limiteds {
+		if limited.Resell.Enabled() {
+			itemRules[limited.Id] = limited.Resell
+		}
+	}
+	statePath = cfg.StateFile
+	maxAttempts = cfg.MaxAttempts
+
+	restored, err := readState()
+	if err != nil {
+		mu.Unlock()
+		return err
+	}
+	jobs = restored
+	enabled := len(itemRules) > 0 || len(jobs) > 0
+	mu.Unlock()
+
+	if !enabled {
+		return nil
+	}
+	if len(restored) > 0 {
+		logger.Info("Restored pending resells", "Count", len(restored))
+	}
+
+	events.Subscribe("resell", 0, func(event events.Event) {
+		bought := event.(events.PurchaseSucceeded)
+
+		mu.Lock()
+		rule, ok := itemRules[bought.LimitedID]
+		if ok {
+			jobs = append(jobs, &Job{
+				LimitedID:   bought.LimitedID,
+				ItemName:    bought.ItemName,
+				UserAssetID: bought.UserAssetID,
+				Paid:        bought.Price,
+				Rule:        rule,
+				Attempt:     bought.Attempt,
+				BoughtAt:    bought.Time,
+			})
+			persist()
+		}
+		mu.Unlock()
+
+		if ok {
+			Wake()
+		}
+	}, events.KindPurchaseSucceeded)
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			process()
+
+			select {
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wake checks the pending resells right away, it never blocks.
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns a copy of the resells waiting for their hold period or a retry.
+func Pending() []Job {
+	mu.Lock()
+	defer mu.Unlock()
+
+	pending := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		pending = append(pending, *job)
+	}
+	return pending
+}
+
+// process tries to list every pending copy, jobs are done once listed or given up.
+func process() {
+	if !session.Active() {
+		return
+	}
+
+	mu.Lock()
+	pending := append([]*Job{}, jobs...)
+	mu.Unlock()
+
+	for _, job := range pending {
+		if done := list(job); done {
+			remove(job)
+		}
+	}
+}
+
+// list puts a single copy on sale, it returns true when the job is finished either way.
+func list(job *Job) bool {
+	log := logging.Attempt("resell", job.Attempt).With("Limited ID", job.LimitedID, "User Asset ID", job.UserAssetID)
+	cookie := session.Cookie()
+
+	copies, err := scraper.FetchResellableCopies(cookie, job.LimitedID, session.User().Id)
+	if err != nil {
+		return retry(job, fmt.Errorf("could not fetch resellable copies: %w", err))
+	}
+
+	var owned *scraper.ResellableCopy
+	for i := range copies {
+		if copies[i].UserAssetID == job.UserAssetID {
+			owned = &copies[i]
+			break
+		}
+	}
+
+	switch {
+	case owned == nil && time.Since(job.BoughtAt) > giveUpAfter:
+		return fail(job, fmt.Errorf("the copy never showed up in the inventory"))
+	case owned == nil:
+		log.Debug("Copy not in the inventory yet, waiting.")
+		return false
+	case owned.IsOnHold:
+		log.Debug("Copy is on hold, waiting.")
+		return false
+	case owned.Price > 0:
+		log.Info("Copy is already on sale, leaving its price alone.", "Price", owned.Price)
+		return true
+	}
+
+	var rap int
+	if job.Rule.Mode == parser.ResellRAP {
+		resale, err := scraper.FetchResaleData(cookie, job.LimitedID)
+		if err != nil {
+			return retry(job, fmt.Errorf("could not fetch resale data: %w", err))
+		}
+		rap = resale.RecentAveragePrice
+	}
+
+	price, err := rules.ResalePrice(job.Rule, job.Paid, rap)
+	if err != nil {
+		return fail(job, err)
+	}
+
+	token, err := csrf.GetCSRF(cookie)
+	if err != nil {
+		return retry(job, err)
+	}
+
+	if err := purchase.SetResalePrice(token, cookie, job.LimitedID, job.UserAssetID, price); err != nil {
+		return retry(job, err)
+	}
+
+	events.Publish(events.ResaleListed{
+		Header:      events.Stamp(job.Attempt, job.LimitedID),
+		ItemName:    job.ItemName,
+		UserAssetID: job.UserAssetID,
+		Paid:        job.Paid,
+		Price:       price,
+		Rule:        job.Rule.String(),
+	})
+	return true
+}
+
+// retry counts a failed try, the job is given up after max_attempts.
+func retry(job *Job, err error) bool {
+	mu.Lock()
+	job.Tries++
+	tries := job.Tries
+	persist()
+	mu.Unlock()
+
+	if tries >= maxAttempts {
+		return fail(job, err)
+	}
+
+	logging.Attempt("resell", job.Attempt).Warn("Resell failed, will retry.", "Limited ID", job.LimitedID, "Tries", tries, "Error", err)
+	return false
+}
+
+func fail(job *Job, err error) bool {
+	events.Publish(events.ResaleFailed{
+		Header:      events.Stamp(job.Attempt, job.LimitedID),
+		ItemName:    job.ItemName,
+		UserAssetID: job.UserAssetID,
+		Paid:        job.Paid,
+		Rule:        job.Rule.String(),
+		Message:     err.Error(),
+	})
+	return true
+}
+
+func remove(job *Job) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, pending := range jobs {
+		if pending == job {
+			jobs = append(jobs[:i], jobs[i+1:]...)
+			break
+		}
+	}
+	persist()
+}
+
+// persist writes the pending jobs, callers hold mu.
+func persist() {
+	if statePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		logger.Error("Failed to marshal pending resells", "Error", err)
+		return
+	}
+
+	// Write to a temporary file first so a crash never leaves a half-written state behind
+	tmp := statePath + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err == nil {
+		err = os.WriteFile(tmp, data, 0o600)
+		if err == nil {
+			err = os.Rename(tmp, statePath)
+		}
+		if err != nil {
+			logger.Error("Failed to persist pending resells", "Error", err)
+		}
+	}
+}
+
+func readState() ([]*Job, error) {
+	if statePath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending resells: %w", err)
+	}
+
+	var restored []*Job
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return nil, fmt.Errorf("failed to decode pending resells: %w", err)
+	}
+	return restored, nil
+}
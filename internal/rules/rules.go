@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"sniper/internal/events"
+	"sniper/internal/parser"
+)
+
+// ShouldBuy is the buy decision of a worker for the cheapest listing of its item.
+// The backtest replays recorded listings through the same function.
+func ShouldBuy(limited parser.LimitedInfo, listing events.Listing) bool {
+	return listing.Price > 0 && listing.Price <= limited.Price
+}
+
+// UnderCap reports whether owning one more copy stays within the item's max_owned limit.
+func UnderCap(limited parser.LimitedInfo, owned int) bool {
+	return limited.MaxOwned <= 0 || owned < limited.MaxOwned
+}
+
+// ResalePrice prices a sniped copy with the item's resell rule, rap is only used by the rap mode.
+func ResalePrice(rule parser.ResellRule, paid, rap int) (int, error) {
+	var price float64
+	switch rule.Mode {
+	case parser.ResellFixed:
+		price = rule.Value
+	case parser.ResellMarkup:
+		price = float64(paid) * (1 + rule.Value/100)
+	case parser.ResellRAP:
+		if rap <= 0 {
+			return 0, fmt.Errorf("the item has no RAP to price from")
+		}
+		price = float64(rap) * rule.Value / 100
+	default:
+		return 0, fmt.Errorf("no resell rule")
+	}
+
+	if price < 1 {
+		return 0, fmt.Errorf("resell rule %s gives a price below R$ 1", rule)
+	}
+	return int(math.Ceil(price)), nil
+}
+
+// Proceeds is what a sale at price pays out after the marketplace fee.
+func Proceeds(price int, feePercent float64) int {
+	return int(math.Floor(float64(price) * (1 - feePercent/100)))
+}
+
+// Reprice returns the price that makes our listing the lowest again, undercutting the
+// cheapest competing listing by undercut. It reports false when the listing should stay
+// as it is: already the lowest, no floor set, or the floor would be crossed.
+func Reprice(price, lowest, floor, undercut int) (int, bool) {
+	if floor <= 0 || lowest <= 0 || price < lowest {
+		return price, false
+	}
+
+	target := lowest - undercut
+	if target < floor {
+		target = floor
+	}
+	if target >= price {
+		return price, false
+	}
+	return target, true
+}
+
+// Discount is how far below the RAP a price is, in percent.
+func Discount(price, rap int) float64 {
+	if rap <= 0 {
+		return 0
+	}
+	return float64(rap-price) / float64(rap) * 100
+}
+
+// DiscoveryTarget is the target a discovered item is watched with, percent of its RAP.
+func DiscoveryTarget(rap int, percent float64) int {
+	return int(math.Floor(float64(rap) * percent / 100))
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"sniper/internal/events"
+	"sniper/internal/parser"
+	"testing"
+)
+
+func TestShouldBuy(t *testing.T) {
+	limited := parser.LimitedInfo{Id: "1234", Price: 500}
+
+	tests := []struct {
+		name  string
+		price int
+		want  bool
+	}{
+		{"below the target", 499, true},
+		{"at the target", 500, true},
+		{"above the target", 501, false},
+		{"no listing", 0, false},
+		{"failed scrape", -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldBuy(limited, events.Listing{Price: tt.price}); got != tt.want {
+				t.Errorf("ShouldBuy at R$ %d = %v, want %v", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReprice(t *testing.T) {
+	tests := []struct {
+		name                           string
+		price, lowest, floor, undercut int
+		want                           int
+		ok                             bool
+	}{
+		{"undercuts the lowest", 900, 800, 500, 1, 799, true},
+		{"undercuts by more", 900, 800, 500, 50, 750, true},
+		{"stops at the floor", 900, 520, 500, 50, 500, true},
+		{"lowest below the floor", 900, 400, 500, 1, 500, true},
+		{"already the lowest", 700, 800, 500, 1, 700, false},
+		{"already at the floor", 500, 400, 500, 1, 500, false},
+		{"no floor", 900, 800, 0, 1, 900, false},
+		{"no competing listing", 900, 0, 500, 1, 900, false},
+		{"tied with the lowest", 800, 800, 500, 1, 799, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Reprice(tt.price, tt.lowest, tt.floor, tt.undercut)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Reprice(%d, %d, %d, %d) = %d, %v, want %d, %v",
+					tt.price, tt.lowest, tt.floor, tt.undercut, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestProceeds(t *testing.T) {
+	tests := []struct {
+		price int
+		fee   float64
+		want  int
+	}{
+		{1000, 30, 700},
+		{999, 30, 699}, // 699.3, rounded down like Roblox does
+		{1, 30, 0},
+		{1000, 0, 1000},
+		{1000, 12.5, 875},
+	}
+
+	for _, tt := range tests {
+		if got := Proceeds(tt.price, tt.fee); got != tt.want {
+			t.Errorf("Proceeds(%d, %v) = %d, want %d", tt.price, tt.fee, got, tt.want)
+		}
+	}
+}
+
+func TestResalePrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      parser.ResellRule
+		paid, rap int
+		want      int
+		err       bool
+	}{
+		{"fixed", parser.ResellRule{Mode: parser.ResellFixed, Value: 1500}, 500, 0, 1500, false},
+		{"markup rounds up", parser.ResellRule{Mode: parser.ResellMarkup, Value: 15}, 333, 0, 383, false},
+		{"rap", parser.ResellRule{Mode: parser.ResellRAP, Value: 95}, 500, 1000, 950, false},
+		{"rap without a rap", parser.ResellRule{Mode: parser.ResellRAP, Value: 95}, 500, 0, 0, true},
+		{"below R$ 1", parser.ResellRule{Mode: parser.ResellRAP, Value: 10}, 500, 5, 0, true},
+		{"no rule", parser.ResellRule{}, 500, 1000, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResalePrice(tt.rule, tt.paid, tt.rap)
+			if (err != nil) != tt.err || got != tt.want {
+				t.Errorf("ResalePrice = %d, %v, want %d (error %v)", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
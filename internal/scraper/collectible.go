@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"fmt"
+	"sniper/internal/parser"
+	"sync"
+)
+
+type collectibleIDs struct {
+	ItemID    string
+	ProductID string
+}
+
+// "<type>:<id>" -> collectible ids, they never change for an item so they are looked up once.
+var collectibles = sync.Map{}
+
+type CollectibleSeller struct {
+	SellerID   int    `json:"sellerId"`
+	SellerType string `json:"sellerType"`
+	Name       string `json:"name"`
+}
+
+type CollectibleReseller struct {
+	CollectibleProductID  string            `json:"collectibleProductId"`
+	CollectibleInstanceID string            `json:"collectibleItemInstanceId"`
+	Seller                CollectibleSeller `json:"seller"`
+	Price                 int               `json:"price"`
+	SerialNumber          int               `json:"serialNumber"`
+}
+
+type collectibleResellers struct {
+	Data []CollectibleReseller `json:"data"`
+}
+
+// FetchListing returns the cheapest listing of a limited through the flow of its kind.
+func FetchListing(cookie string, limited parser.LimitedInfo) (ScrapedDetails, error) {
+	if limited.Kind == parser.KindUGC {
+		return FetchCollectibleListing(cookie, limited)
+	}
+	return ScrapeItemDetails(cookie, limited.Id)
+}
+
+// FetchCollectibleListing returns the cheapest resale listing of a UGC limited or a limited bundle.
+// The price is 0 when nobody is reselling it.
+func FetchCollectibleListing(cookie string, limited parser.LimitedInfo) (ScrapedDetails, error) {
+	var ret ScrapedDetails
+
+	ids, err := collectibleIDsOf(cookie, limited)
+	if err != nil {
+		return ret, err
+	}
+	ret.CollectibleItemID = ids.ItemID
+	ret.CollectibleProductID = ids.ProductID
+
+	// Resellers are sorted by price, the first one is the cheapest
+	var resellers collectibleResellers
+	url := fmt.Sprintf("https://apis.roblox.com/marketplace-sales/v1/item/%s/resellers?limit=1", ids.ItemID)
+	if err := getJSON(cookie, url, &resellers); err != nil {
+		return ret, fmt.Errorf("could not fetch resellers: %w", err)
+	}
+
+	if len(resellers.Data) == 0 {
+		return ret, nil
+	}
+
+	lowest := resellers.Data[0]
+	ret.Price = lowest.Price
+	ret.SellerID = lowest.Seller.SellerID
+	ret.CollectibleInstanceID = lowest.CollectibleInstanceID
+	ret.SerialNumber = lowest.SerialNumber
+	if lowest.CollectibleProductID != "" {
+		ret.CollectibleProductID = lowest.CollectibleProductID
+	}
+
+	return ret, nil
+}
+
+func collectibleIDsOf(cookie string, limited parser.LimitedInfo) (collectibleIDs, error) {
+	key := limited.Type + ":" + limited.Id
+	if cached, ok := collectibles.Load(key); ok {
+		return cached.(collectibleIDs), nil
+	}
+
+	var ids collectibleIDs
+	if limited.Type == parser.TypeBundle {
+		// The product id of a bundle comes with every reseller instead
+		details, err := FetchCatalogDetails(cookie, limited.Id, limited.CatalogType())
+		if err != nil {
+			return ids, fmt.Errorf("could not fetch bundle details: %w", err)
+		}
+		if details.CollectibleItemID == "" {
+			return ids, fmt.Errorf("bundle %s is not a limited", limited.Id)
+		}
+		ids.ItemID = details.CollectibleItemID
+	} else {
+		details, err := FetchAssetDetails(cookie, limited.Id)
+		if err != nil {
+			return ids, fmt.Errorf("could not fetch asset details: %w", err)
+		}
+		if details.CollectibleItemID == "" {
+			return ids, fmt.Errorf("asset %s is not a UGC limited, drop kind=ugc from its line", limited.Id)
+		}
+		ids = collectibleIDs{ItemID: details.CollectibleItemID, ProductID: details.CollectibleProductID}
+	}
+
+	// A drop may not have its collectible product before the release, look it up again next time
+	if ids.ProductID != "" || limited.Type == parser.TypeBundle {
+		collectibles.Store(key, ids)
+	}
+	return ids, nil
+}
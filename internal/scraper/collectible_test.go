@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sniper/internal/parser"
+	"testing"
+)
+
+// redirect sends every request to the fixture server, whatever Roblox host it was for.
+type redirect struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return r.base.RoundTrip(req)
+}
+
+// fixture serves mux in place of the Roblox APIs, for both the API client and the catalog page
+// scraper (colly goes through http.DefaultTransport).
+func fixture(t *testing.T, mux *http.ServeMux) {
+	t.Helper()
+
+	server := httptest.NewServer(mux)
+	target, _ := url.Parse(server.URL)
+
+	base := http.DefaultTransport
+	previous := apiClient.Transport
+	http.DefaultTransport = redirect{target: target, base: base}
+	apiClient.Transport = redirect{target: target, base: base}
+
+	t.Cleanup(func() {
+		http.DefaultTransport = base
+		apiClient.Transport = previous
+		server.Close()
+	})
+}
+
+// requireCookie fails the request when the .ROBLOSECURITY cookie was not sent.
+func requireCookie(t *testing.T, r *http.Request) {
+	t.Helper()
+	if c, err := r.Cookie(".ROBLOSECURITY"); err != nil || c.Value != "cookie" {
+		t.Errorf("%s %s: missing .ROBLOSECURITY cookie", r.Method, r.URL.Path)
+	}
+}
+
+func TestFetchListingClassic(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog/1001/", func(w http.ResponseWriter, r *http.Request) {
+		requireCookie(t, r)
+		fmt.Fprint(w, `<div id="item-container" data-product-id="555" data-expected-price="420"
+			data-expected-seller-id="77" data-lowest-private-sale-userasset-id="9001"></div>`)
+	})
+	fixture(t, mux)
+
+	got, err := FetchListing("cookie", parser.LimitedInfo{Id: "1001", Kind: parser.KindClassic, Type: parser.TypeAsset})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ScrapedDetails{ProductID: 555, Price: 420, SellerID: 77, UserAssetID: 9001}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFetchListingUGC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/assets/2001/details", func(w http.ResponseWriter, r *http.Request) {
+		requireCookie(t, r)
+		fmt.Fprint(w, `{"AssetId": 2001, "Name": "Hat", "IsLimited": false,
+			"CollectibleItemId": "item-2001", "CollectibleProductId": "product-2001"}`)
+	})
+	mux.HandleFunc("GET /marketplace-sales/v1/item/item-2001/resellers", func(w http.ResponseWriter, r *http.Request) {
+		requireCookie(t, r)
+		if limit := r.URL.Query().Get("limit"); limit != "1" {
+			t.Errorf("resellers limit = %q, want 1", limit)
+		}
+		fmt.Fprint(w, `{"data": [{"collectibleProductId": "resale-product-2001", "collectibleItemInstanceId": "instance-7",
+			"seller": {"sellerId": 88, "sellerType": "User", "name": "seller"}, "price": 350, "serialNumber": 12}]}`)
+	})
+	fixture(t, mux)
+
+	got, err := FetchListing("cookie", parser.LimitedInfo{Id: "2001", Kind: parser.KindUGC, Type: parser.TypeAsset})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ScrapedDetails{
+		Price:                 350,
+		SellerID:              88,
+		CollectibleItemID:     "item-2001",
+		CollectibleProductID:  "resale-product-2001",
+		CollectibleInstanceID: "instance-7",
+		SerialNumber:          12,
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFetchListingUGCWithoutResellers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/assets/2002/details", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"AssetId": 2002, "CollectibleItemId": "item-2002", "CollectibleProductId": "product-2002"}`)
+	})
+	mux.HandleFunc("GET /marketplace-sales/v1/item/item-2002/resellers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	fixture(t, mux)
+
+	got, err := FetchListing("cookie", parser.LimitedInfo{Id: "2002", Kind: parser.KindUGC, Type: parser.TypeAsset})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ScrapedDetails{CollectibleItemID: "item-2002", CollectibleProductID: "product-2002"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFetchListingUGCRejectsClassicAsset(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/assets/2003/details", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"AssetId": 2003, "IsLimited": true}`)
+	})
+	fixture(t, mux)
+
+	if _, err := FetchListing("cookie", parser.LimitedInfo{Id: "2003", Kind: parser.KindUGC, Type: parser.TypeAsset}); err == nil {
+		t.Error("a classic limited watched with kind=ugc must fail")
+	}
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"fmt"
+	"sniper/internal/parser"
+)
+
+// FetchDrop returns the creator's listing of a new drop. onSale is false while the item is
+// off sale or sold out, the listing is empty then.
+func FetchDrop(cookie string, limited parser.LimitedInfo) (listing ScrapedDetails, onSale bool, err error) {
+	details, err := FetchCatalogDetails(cookie, limited.Id, limited.CatalogType())
+	if err != nil {
+		return listing, false, fmt.Errorf("could not fetch catalog details: %w", err)
+	}
+
+	if details.CollectibleItemID != "" && limited.Kind != parser.KindUGC {
+		return listing, false, fmt.Errorf("item %s is a UGC limited, add kind=ugc to its line", limited.Id)
+	}
+
+	if !details.OnSale() {
+		return listing, false, nil
+	}
+
+	listing = ScrapedDetails{
+		ProductID:  details.ProductID,
+		Price:      details.Price,
+		SellerID:   details.CreatorTargetID,
+		SellerType: details.CreatorType,
+	}
+
+	// Looked up once the item is on sale, the collectible product may not exist before the release
+	if limited.Kind == parser.KindUGC {
+		ids, err := collectibleIDsOf(cookie, limited)
+		if err != nil {
+			return listing, false, err
+		}
+		listing.CollectibleItemID = ids.ItemID
+		listing.CollectibleProductID = ids.ProductID
+	}
+
+	return listing, true, nil
+}
@@ -0,0 +1,196 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sniper/internal/parser"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type AssetDetails struct {
+	AssetId     int    `json:"AssetId"`
+	ProductId   int    `json:"ProductId"`
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+	IsLimited   bool   `json:"IsLimited"`
+	IsForSale   bool   `json:"IsForSale"`
+
+	CollectibleItemID    string `json:"CollectibleItemId"`    // Empty for classic limiteds
+	CollectibleProductID string `json:"CollectibleProductId"` // Empty for classic limiteds
+}
+
+// CatalogDetails is what the catalog knows about an asset or bundle.
+type CatalogDetails struct {
+	Id                int    `json:"id"`
+	ItemType          string `json:"itemType"`
+	Name              string `json:"name"`
+	CollectibleItemID string `json:"collectibleItemId"` // Empty unless the item is a collectible
+	LowestResalePrice int    `json:"lowestResalePrice"`
+
+	// Primary sale by the creator, watched by new-drop workers
+	ProductID       int    `json:"productId"`
+	Price           int    `json:"price"`
+	PriceStatus     string `json:"priceStatus"` // "Off Sale" until the item is released
+	IsOffSale       bool   `json:"isOffSale"`
+	UnitsAvailable  int    `json:"unitsAvailableForConsumption"`
+	TotalQuantity   int    `json:"totalQuantity"`
+	CreatorTargetID int    `json:"creatorTargetId"`
+	CreatorType     string `json:"creatorType"` // User or Group
+}
+
+// OnSale reports whether the creator is selling the item right now.
+func (d CatalogDetails) OnSale() bool {
+	if d.IsOffSale || d.PriceStatus == "Off Sale" || d.Price <= 0 {
+		return false
+	}
+	// Limited items report the copies left, 0 means sold out
+	return d.TotalQuantity == 0 || d.UnitsAvailable > 0
+}
+
+type ResaleData struct {
+	RecentAveragePrice int `json:"recentAveragePrice"`
+	OriginalPrice      int `json:"originalPrice"`
+	AssetStock         int `json:"assetStock"`
+	NumberRemaining    int `json:"numberRemaining"`
+	Sales              int `json:"sales"`
+}
+
+// apiClient serves the API lookups of the monitors, a stalled connection must never hang them.
+var apiClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// getJSON performs an authenticated GET and decodes the JSON body into out.
+func getJSON(cookie, url string, out interface{}) error {
+	req, err := http.NewRequest("GET", url, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{
+			Name:  ".ROBLOSECURITY",
+			Value: cookie,
+		})
+	}
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// FetchAssetDetails returns the catalog details (name, product id...) of an asset.
+func FetchAssetDetails(cookie, assetID string) (AssetDetails, error) {
+	var details AssetDetails
+	err := getJSON(cookie, fmt.Sprintf("https://economy.roblox.com/v2/assets/%s/details", assetID), &details)
+	return details, err
+}
+
+// FetchCatalogDetails returns the catalog details of an item, itemType is "Asset" or "Bundle".
+func FetchCatalogDetails(cookie, id, itemType string) (CatalogDetails, error) {
+	var details CatalogDetails
+	err := getJSON(cookie, fmt.Sprintf("https://catalog.roblox.com/v1/catalog/items/%s/details?itemType=%s", id, itemType), &details)
+	return details, err
+}
+
+// FetchItemName returns the display name of a watchlist item, asset or bundle.
+func FetchItemName(cookie string, limited parser.LimitedInfo) (string, error) {
+	if limited.Type == parser.TypeBundle {
+		details, err := FetchCatalogDetails(cookie, limited.Id, limited.CatalogType())
+		return details.Name, err
+	}
+	details, err := FetchAssetDetails(cookie, limited.Id)
+	return details.Name, err
+}
+
+// FetchResaleData returns the resale statistics of a limited, including its RAP.
+func FetchResaleData(cookie, assetID string) (ResaleData, error) {
+	var data ResaleData
+	err := getJSON(cookie, fmt.Sprintf("https://economy.roblox.com/v1/assets/%s/resale-data", assetID), &data)
+	return data, err
+}
+
+type Currency struct {
+	Robux int `json:"robux"`
+}
+
+// FetchBalance returns the Robux balance of the authenticated user.
+func FetchBalance(cookie string, userID int) (int, error) {
+	var currency Currency
+	err := getJSON(cookie, fmt.Sprintf("https://economy.roblox.com/v1/users/%d/currency", userID), &currency)
+	return currency.Robux, err
+}
+
+type Collectible struct {
+	UserAssetID        int    `json:"userAssetId"`
+	SerialNumber       int    `json:"serialNumber"`
+	AssetID            int    `json:"assetId"`
+	Name               string `json:"name"`
+	RecentAveragePrice int    `json:"recentAveragePrice"`
+	IsOnHold           bool   `json:"isOnHold"`
+}
+
+type collectiblesPage struct {
+	NextPageCursor string        `json:"nextPageCursor"`
+	Data           []Collectible `json:"data"`
+}
+
+// FetchCollectibles returns every collectible copy the user owns, following the pages of the inventory API.
+func FetchCollectibles(cookie string, userID int) ([]Collectible, error) {
+	var owned []Collectible
+	cursor := ""
+
+	for {
+		var page collectiblesPage
+		query := url.Values{"limit": {"100"}, "sortOrder": {"Asc"}, "cursor": {cursor}}
+		endpoint := fmt.Sprintf("https://inventory.roblox.com/v1/users/%d/assets/collectibles?%s", userID, query.Encode())
+		if err := getJSON(cookie, endpoint, &page); err != nil {
+			return nil, err
+		}
+
+		owned = append(owned, page.Data...)
+		if page.NextPageCursor == "" {
+			return owned, nil
+		}
+		cursor = page.NextPageCursor
+	}
+}
+
+type ResellableCopy struct {
+	UserAssetID  int  `json:"userAssetId"`
+	SerialNumber int  `json:"serialNumber"`
+	Price        int  `json:"price"` // 0 when not on sale
+	IsOnHold     bool `json:"isOnHold"`
+}
+
+type resellableCopies struct {
+	Data []ResellableCopy `json:"data"`
+}
+
+// FetchResellableCopies returns the copies of an asset the user owns, with their sale price and hold status.
+func FetchResellableCopies(cookie, assetID string, userID int) ([]ResellableCopy, error) {
+	var copies resellableCopies
+	err := getJSON(cookie, fmt.Sprintf("https://economy.roblox.com/v1/assets/%s/users/%d/resellable-copies", assetID, userID), &copies)
+	return copies.Data, err
+}
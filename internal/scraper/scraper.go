@@ -45,7 +45,8 @@ type ResponseBody struct {
 
 // Written By github.com/jub0t
 // Reuse the client, ensuring connection reuse and minimizing overhead.
-func FasterItemDetails(cookie, limited_id string, proxy *parser.SingleProxy) (LimitedAssetResponse, error) {
+// item_type is the catalog spelling of the type, see parser.LimitedInfo.CatalogType.
+func FasterItemDetails(cookie, limited_id, item_type string, proxy *parser.SingleProxy) (LimitedAssetResponse, error) {
 	var response LimitedAssetResponse
 
 	// Build the proxy URL
@@ -71,7 +72,7 @@ func FasterItemDetails(cookie, limited_id string, proxy *parser.SingleProxy) (Li
 	}
 
 	req, err := http.NewRequest("GET",
-		fmt.Sprintf("https://catalog.roblox.com/v1/catalog/items/%s/details?itemType=Asset", limited_id),
+		fmt.Sprintf("https://catalog.roblox.com/v1/catalog/items/%s/details?itemType=%s", limited_id, item_type),
 		nil, // Use http.NoBody or nil for GET requests
 	)
 	if err != nil {
@@ -109,6 +110,9 @@ type AuthenticatedUser struct {
 	Id          int    `json:"id"`
 }
 
+// FetchAuthenticated returns the account the cookie belongs to. Roblox answers 401 for an expired
+// or invalid cookie, which gives an empty user; any other failure is an error and says nothing
+// about the cookie.
 func FetchAuthenticated(cookie string) (AuthenticatedUser, error) {
 	var response AuthenticatedUser
 	url := "https://users.roblox.com/v1/users/authenticated"
@@ -128,8 +132,7 @@ func FetchAuthenticated(cookie string) (AuthenticatedUser, error) {
 		Value: cookie,
 	})
 
-	// Execute the request and measure latency
-	resp, err := Client.Do(req)
+	resp, err := apiClient.Do(req)
 	if err != nil {
 		return response, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -141,6 +144,14 @@ func FetchAuthenticated(cookie string) (AuthenticatedUser, error) {
 		return response, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return response, nil
+	default:
+		return response, fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+
 	// Parse the JSON response
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		return response, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -154,6 +165,13 @@ type ScrapedDetails struct {
 	Price       int `json:"lowestPrice"`
 	SellerID    int `json:"sellerId"`
 	UserAssetID int `json:"userAssetId"`
+
+	// UGC limiteds are bought by collectible ids instead of the product and user asset ids
+	CollectibleItemID     string `json:"collectibleItemId,omitempty"`
+	CollectibleProductID  string `json:"collectibleProductId,omitempty"`
+	CollectibleInstanceID string `json:"collectibleItemInstanceId,omitempty"`
+	SerialNumber          int    `json:"serialNumber,omitempty"`
+	SellerType            string `json:"sellerType,omitempty"` // Set for new drops, whose seller is the creator
 }
 
 func ScrapeItemDetails(cookie, limitedID string) (ScrapedDetails, error) {
@@ -197,14 +215,20 @@ type ThumbnailResponse struct {
 	Data []ThumbnailData `json:"data"`
 }
 
-func GetThumbnail(targetId string) (ThumbnailData, error) {
+// GetThumbnail fetches the thumbnail of an item, itemType is parser.TypeAsset or parser.TypeBundle.
+func GetThumbnail(targetId, itemType string) (ThumbnailData, error) {
 	url := "https://thumbnails.roblox.com/v1/batch"
 
+	thumbnailType := "Asset"
+	if itemType == parser.TypeBundle {
+		thumbnailType = "BundleThumbnail"
+	}
+
 	// Data struct for the POST request, with targetId as a parameter
 	data := []map[string]interface{}{
 		{
-			"requestId": fmt.Sprintf("%s:undefined:Asset:150x150:webp:regular", targetId),
-			"type":      "Asset",
+			"requestId": fmt.Sprintf("%s:undefined:%s:150x150:webp:regular", targetId, thumbnailType),
+			"type":      thumbnailType,
 			"targetId":  targetId,
 			"format":    "webp",
 			"size":      "150x150",
@@ -224,9 +248,8 @@ func GetThumbnail(targetId string) (ThumbnailData, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	// Perform the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Perform the request, notifications wait for it so it must not hang
+	resp, err := apiClient.Do(req)
 	if err != nil {
 		return ThumbnailData{}, fmt.Errorf("error making request: %v", err)
 	}
@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestFetchAuthenticated(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   AuthenticatedUser
+		err    bool
+	}{
+		{"valid cookie", http.StatusOK, `{"id": 42, "name": "sniper", "displayName": "Sniper"}`, AuthenticatedUser{Id: 42, Username: "sniper", DisplayName: "Sniper"}, false},
+		{"expired cookie", http.StatusUnauthorized, `{"errors": [{"code": 0, "message": "Authorization has been denied for this request."}]}`, AuthenticatedUser{}, false},
+		{"answer without a user", http.StatusOK, `{}`, AuthenticatedUser{}, false},
+		{"rate limited", http.StatusTooManyRequests, `{"errors": [{"code": 0, "message": "Too many requests"}]}`, AuthenticatedUser{}, true},
+		{"server error", http.StatusServiceUnavailable, ``, AuthenticatedUser{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/users/authenticated", func(w http.ResponseWriter, r *http.Request) {
+				requireCookie(t, r)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			fixture(t, mux)
+
+			got, err := FetchAuthenticated("cookie")
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
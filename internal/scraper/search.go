@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// SearchFilter narrows a catalog search, zero values are left out of the query.
+type SearchFilter struct {
+	Category    string
+	Subcategory string
+	Creator     string // Creator name
+	Keyword     string
+	MinPrice    int
+	MaxPrice    int
+}
+
+type SearchResult struct {
+	Id                int      `json:"id"`
+	ItemType          string   `json:"itemType"`
+	Name              string   `json:"name"`
+	CreatorName       string   `json:"creatorName"`
+	LowestPrice       int      `json:"lowestPrice"`
+	Price             int      `json:"price"`
+	CollectibleItemID string   `json:"collectibleItemId"`
+	ItemRestrictions  []string `json:"itemRestrictions"`
+}
+
+type searchPage struct {
+	NextPageCursor string         `json:"nextPageCursor"`
+	Data           []SearchResult `json:"data"`
+}
+
+// SearchCatalog returns up to pages pages of collectibles matching the filter, cheapest first.
+func SearchCatalog(cookie string, filter SearchFilter, pages int) ([]SearchResult, error) {
+	query := url.Values{}
+	query.Set("salesTypeFilter", "2") // Collectibles only
+	query.Set("sortType", "3")        // Price, low to high
+	query.Set("limit", "30")
+	if filter.Category != "" {
+		query.Set("category", filter.Category)
+	}
+	if filter.Subcategory != "" {
+		query.Set("subcategory", filter.Subcategory)
+	}
+	if filter.Creator != "" {
+		query.Set("creatorName", filter.Creator)
+	}
+	if filter.Keyword != "" {
+		query.Set("keyword", filter.Keyword)
+	}
+	if filter.MinPrice > 0 {
+		query.Set("minPrice", strconv.Itoa(filter.MinPrice))
+	}
+	if filter.MaxPrice > 0 {
+		query.Set("maxPrice", strconv.Itoa(filter.MaxPrice))
+	}
+
+	var results []SearchResult
+	for page := 0; page < pages; page++ {
+		var found searchPage
+		if err := getJSON(cookie, "https://catalog.roblox.com/v1/search/items/details?"+query.Encode(), &found); err != nil {
+			return results, fmt.Errorf("catalog search failed: %w", err)
+		}
+
+		results = append(results, found.Data...)
+		if found.NextPageCursor == "" {
+			break
+		}
+		query.Set("cursor", found.NextPageCursor)
+	}
+
+	return results, nil
+}
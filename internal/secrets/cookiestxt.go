@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const cookieName = ".ROBLOSECURITY"
+
+// fromCookiesTxt pulls the .ROBLOSECURITY cookie out of a Netscape cookies.txt export.
+// Each line is: domain, include subdomains, path, secure, expiry, name, value (tab separated).
+func fromCookiesTxt(path string) (Secrets, error) {
+	if path == "" {
+		return Secrets{}, nil
+	}
+
+	data, err := readPrivate(path)
+	if err != nil {
+		return Secrets{}, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// Cookie values are long, give the scanner room for them
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Browsers prefix HttpOnly cookies with #HttpOnly_, every other # line is a comment
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+
+		if fields[5] == cookieName && strings.HasSuffix(fields[0], "roblox.com") {
+			return Secrets{Cookie: fields[6]}, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Secrets{}, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	return Secrets{}, fmt.Errorf("no %s cookie for roblox.com in %s", cookieName, path)
+}
@@ -0,0 +1,185 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+// scrypt parameters recommended for interactive logins.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	formatV1     = 1
+	formatHeader = "sniper-secrets"
+)
+
+// EncryptedFile is the on-disk format of an encrypted secrets file.
+type EncryptedFile struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+var (
+	passphrase   string
+	passphraseMu sync.Mutex
+)
+
+// Passphrase returns the passphrase from the environment, or asks for it once on a terminal.
+func Passphrase() (string, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+
+	if passphrase != "" {
+		return passphrase, nil
+	}
+
+	if env := os.Getenv(EnvPassphrase); env != "" {
+		passphrase = env
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no passphrase, set %s", EnvPassphrase)
+	}
+
+	fmt.Fprint(os.Stderr, "Secrets passphrase: ")
+	input, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	passphrase = strings.TrimSpace(string(input))
+	if passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+
+	return passphrase, nil
+}
+
+func deriveKey(pass string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(pass), salt, scryptN, scryptR, scryptP, keyLength)
+}
+
+// Encrypt seals the secrets with a key derived from the passphrase (scrypt + AES-256-GCM).
+func Encrypt(secrets Secrets, pass string) ([]byte, error) {
+	plaintext, err := yaml.Marshal(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secrets: %w", err)
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := deriveKey(pass, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return json.MarshalIndent(EncryptedFile{
+		Format:     formatHeader,
+		Version:    formatV1,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(formatHeader)),
+	}, "", "  ")
+}
+
+// Decrypt opens a file produced by Encrypt.
+func Decrypt(data []byte, pass string) (Secrets, error) {
+	var secrets Secrets
+
+	var file EncryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return secrets, fmt.Errorf("not an encrypted secrets file: %w", err)
+	}
+	if file.Format != formatHeader || file.Version != formatV1 {
+		return secrets, fmt.Errorf("unsupported secrets file format %q v%d", file.Format, file.Version)
+	}
+
+	key, err := deriveKey(pass, file.Salt)
+	if err != nil {
+		return secrets, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return secrets, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return secrets, err
+	}
+
+	if len(file.Nonce) != gcm.NonceSize() {
+		return secrets, errors.New("corrupted secrets file")
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, []byte(formatHeader))
+	if err != nil {
+		return secrets, errors.New("wrong passphrase or corrupted secrets file")
+	}
+
+	if err := yaml.Unmarshal(plaintext, &secrets); err != nil {
+		return secrets, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+
+	return secrets, nil
+}
+
+func fromEncryptedFile(path, fallback string) (Secrets, error) {
+	path, ok := resolvePath(path, fallback)
+	if !ok {
+		return Secrets{}, nil
+	}
+
+	data, err := readPrivate(path)
+	if err != nil {
+		return Secrets{}, err
+	}
+
+	pass, err := Passphrase()
+	if err != nil {
+		return Secrets{}, err
+	}
+
+	secrets, err := Decrypt(data, pass)
+	if err != nil {
+		return Secrets{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return secrets, nil
+}
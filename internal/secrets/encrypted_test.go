@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	secrets := Secrets{Cookie: "_|WARNING:-DO-NOT-SHARE-THIS.|_cookie", WebhookURL: "https://discord.com/api/webhooks/1/token"}
+
+	data, err := Encrypt(secrets, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "DO-NOT-SHARE") || strings.Contains(string(data), "discord.com") {
+		t.Fatal("the encrypted file contains a secret in the clear")
+	}
+
+	got, err := Decrypt(data, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != secrets {
+		t.Errorf("got %+v, want %+v", got, secrets)
+	}
+
+	// A fresh salt and nonce every time
+	again, err := Encrypt(secrets, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) == string(data) {
+		t.Error("encrypting twice gave the same file")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	data, err := Encrypt(Secrets{Cookie: "cookie"}, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file EncryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	modified := func(change func(*EncryptedFile)) []byte {
+		copied := file
+		copied.Ciphertext = append([]byte{}, file.Ciphertext...)
+		change(&copied)
+		data, _ := json.Marshal(copied)
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		pass string
+		err  string
+	}{
+		{"wrong passphrase", data, "battery staple", "wrong passphrase"},
+		{"empty passphrase", data, "", "wrong passphrase"},
+		{"tampered ciphertext", modified(func(f *EncryptedFile) { f.Ciphertext[0] ^= 1 }), "correct horse", "wrong passphrase"},
+		{"other salt", modified(func(f *EncryptedFile) { f.Salt = make([]byte, saltLength) }), "correct horse", "wrong passphrase"},
+		{"short nonce", modified(func(f *EncryptedFile) { f.Nonce = f.Nonce[:4] }), "correct horse", "corrupted secrets file"},
+		{"newer version", modified(func(f *EncryptedFile) { f.Version = 2 }), "correct horse", "unsupported secrets file format"},
+		{"plain yaml", []byte("cookie: abc\n"), "correct horse", "not an encrypted secrets file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(tt.data, tt.pass)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sniper/internal/config"
+	"sniper/internal/logging"
+
+	"gopkg.in/yaml.v2"
+)
+
+var logger = logging.For("secrets")
+
+// Environment variables, they win over every file.
+const (
+	EnvCookie     = "SNIPER_COOKIE"
+	EnvWebhookURL = "SNIPER_WEBHOOK_URL"
+	EnvPassphrase = "SNIPER_SECRETS_PASSPHRASE"
+)
+
+// Files picked up when they exist and the config does not point elsewhere.
+const (
+	DefaultFile          = "secrets.yaml"
+	DefaultEncryptedFile = "secrets.enc"
+)
+
+// Secrets is the content of a secrets file, plain or encrypted.
+type Secrets struct {
+	Cookie     string `yaml:"cookie"`
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// source is one place credentials can come from, in precedence order.
+type source struct {
+	name       string
+	cookieOnly bool // Never holds the webhook URL
+	load       func() (Secrets, error)
+}
+
+// Apply fills cfg.Cookie and cfg.WebhookURL, each from the first source that has it.
+// Precedence: environment, secrets file, encrypted secrets file, cookies.txt, config.yaml.
+// A source is only read while it can still supply a missing value, so an encrypted file below
+// the one that had everything is never opened and never asks for its passphrase. Once the
+// cookie is known a failing source is skipped with a warning, the webhook URL is optional.
+func Apply(cfg *config.ConfigStruct) error {
+	sources := []source{
+		{name: "environment", load: fromEnv},
+		{name: "secrets file", load: func() (Secrets, error) {
+			return fromFile(cfg.Secrets.File, DefaultFile)
+		}},
+		{name: "encrypted secrets file", load: func() (Secrets, error) {
+			return fromEncryptedFile(cfg.Secrets.EncryptedFile, DefaultEncryptedFile)
+		}},
+		{name: "cookies.txt", cookieOnly: true, load: func() (Secrets, error) {
+			return fromCookiesTxt(cfg.Secrets.CookiesTxt)
+		}},
+		{name: "config.yaml", load: func() (Secrets, error) {
+			return Secrets{Cookie: cfg.Cookie, WebhookURL: cfg.WebhookURL}, nil
+		}},
+	}
+
+	var resolved Secrets
+	for _, src := range sources {
+		needCookie := resolved.Cookie == ""
+		needWebhook := resolved.WebhookURL == "" && !src.cookieOnly
+		if !needCookie && !needWebhook {
+			continue
+		}
+
+		found, err := src.load()
+		if err != nil {
+			if needCookie {
+				return fmt.Errorf("%s: %w", src.name, err)
+			}
+			logger.Warn("Skipping a secrets source, the webhook URL is looked up further down.", "Source", src.name, "Error", err)
+			continue
+		}
+
+		if needCookie && found.Cookie != "" {
+			resolved.Cookie = found.Cookie
+			logger.Info("🔑 Cookie Loaded", "Source", src.name)
+			if src.name == "config.yaml" {
+				logger.Warn("The cookie is stored in plain text in config.yaml, consider moving it to a secrets file or " + EnvCookie + ".")
+			}
+		}
+
+		if needWebhook && found.WebhookURL != "" {
+			resolved.WebhookURL = found.WebhookURL
+		}
+	}
+
+	cfg.Cookie = resolved.Cookie
+	cfg.WebhookURL = resolved.WebhookURL
+
+	return nil
+}
+
+func fromEnv() (Secrets, error) {
+	return Secrets{
+		Cookie:     os.Getenv(EnvCookie),
+		WebhookURL: os.Getenv(EnvWebhookURL),
+	}, nil
+}
+
+// fromFile reads a plain YAML secrets file. The default path is optional, a configured one is not.
+func fromFile(path, fallback string) (Secrets, error) {
+	var secrets Secrets
+
+	path, ok := resolvePath(path, fallback)
+	if !ok {
+		return secrets, nil
+	}
+
+	data, err := readPrivate(path)
+	if err != nil {
+		return secrets, err
+	}
+
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return secrets, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return secrets, nil
+}
+
+// resolvePath returns the configured path, or the default one when it exists.
+func resolvePath(path, fallback string) (string, bool) {
+	if path != "" {
+		return path, true
+	}
+
+	if fallback == "" {
+		return "", false
+	}
+
+	if _, err := os.Stat(fallback); err != nil {
+		return "", false
+	}
+
+	return fallback, true
+}
+
+// readPrivate reads a file that may only be accessible by its owner (0600 or stricter).
+func readPrivate(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	// Windows has no unix permission bits to check
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("refusing to read %s, permissions %04o are too loose (run: chmod 600 %s)", path, info.Mode().Perm(), path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return data, nil
+}
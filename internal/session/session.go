@@ -0,0 +1,139 @@
+package session
+
+import (
+	"sniper/internal/csrf"
+	"sniper/internal/logging"
+	"sniper/internal/scraper"
+	"sniper/internal/webhook"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("session")
+
+const DefaultCheckInterval = 60 * time.Second
+
+var (
+	cookie string
+	lost   bool
+	user   scraper.AuthenticatedUser
+	mu     sync.RWMutex
+	check  = make(chan struct{}, 1)
+)
+
+// Init sets the cookie every authenticated request should use and the account it belongs to.
+func Init(initial string, authenticated scraper.AuthenticatedUser) {
+	mu.Lock()
+	defer mu.Unlock()
+	cookie = initial
+	user = authenticated
+	lost = authenticated.Id <= 0
+}
+
+// Cookie returns the current .ROBLOSECURITY cookie.
+func Cookie() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return cookie
+}
+
+// Active reports whether the session is usable, purchases must not be attempted otherwise.
+func Active() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return !lost
+}
+
+// User returns the account the session was last verified as.
+func User() scraper.AuthenticatedUser {
+	mu.RLock()
+	defer mu.RUnlock()
+	return user
+}
+
+// SetCookie swaps in a new cookie (e.g. from a config reload) and verifies it right away.
+func SetCookie(updated string) {
+	mu.Lock()
+	changed := updated != cookie
+	cookie = updated
+	mu.Unlock()
+
+	if changed {
+		logger.Info("🔑 New cookie received, verifying session.")
+		CheckNow()
+	}
+}
+
+// CheckNow asks the monitor to verify the session as soon as possible, it never blocks.
+func CheckNow() {
+	select {
+	case check <- struct{}{}:
+	default:
+	}
+}
+
+// Start verifies the session every interval, or sooner when CheckNow is called.
+func Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-check:
+			}
+			verify()
+		}
+	}()
+}
+
+// verify asks Roblox who we are. Network errors, rate limits and server errors say nothing about
+// the cookie, only a 401 or an answer without a user means the session is gone.
+func verify() {
+	current := Cookie()
+
+	authenticated, err := scraper.FetchAuthenticated(current)
+	if err != nil {
+		logger.Warn("Could not verify session, will retry.", "Error", err)
+		return
+	}
+
+	mu.Lock()
+	was_lost := lost
+	lost = authenticated.Id <= 0
+	if !lost {
+		user = authenticated
+	}
+	mu.Unlock()
+
+	switch {
+	case lost && !was_lost:
+		logger.Error("🚨 Session Lost, purchases are stopped until a new cookie is provided through the config.")
+		webhook.Notify(webhook.Message{
+			Event:       webhook.EventSessionLost,
+			Title:       "Session Lost",
+			Description: "The `.ROBLOSECURITY` cookie is no longer valid.\nPurchases are stopped until a new cookie is put in the config.",
+			Color:       0xe03b3b,
+			Urgent:      true,
+		})
+	case !lost && was_lost:
+		// The old token belongs to the old session
+		csrf.Invalidate()
+		if err := csrf.UpdateCSRF(current); err != nil {
+			logger.Error("Could not fetch a CSRF token for the new session.", "Error", err)
+		}
+
+		logger.Info("Session Restored, resuming purchases.", "Username", authenticated.Username, "Id", authenticated.Id)
+		webhook.Notify(webhook.Message{
+			Event:       webhook.EventSessionLost,
+			Title:       "Session Restored",
+			Description: "Authenticated as `" + authenticated.Username + "`, purchases are resumed.",
+			Color:       0x3ba55d,
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package tape
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Items lists the limited ids that have a tape in dir.
+func Items(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+extension))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var ids []string
+	for _, match := range matches {
+		id := strings.SplitN(filepath.Base(match), ".", 2)[0]
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// Read calls fn for every record of an item in time order, rotated files first.
+func Read(dir, limitedID string, fn func(Record) error) error {
+	paths, err := rotatedFiles(dir, limitedID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(currentPath(dir, limitedID)); err == nil {
+		paths = append(paths, currentPath(dir, limitedID))
+	}
+
+	for _, path := range paths {
+		if err := readFile(path, limitedID, fn); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func readFile(path, limitedID string, fn func(Record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return readRecords(bufio.NewReader(f), limitedID, fn)
+}
+
+// exported is the shape of a record in CSV and JSON exports.
+type exported struct {
+	Time        time.Time `json:"time"`
+	LimitedID   string    `json:"limited_id"`
+	Price       int       `json:"price"`
+	SellerID    int       `json:"seller_id"`
+	UserAssetID int       `json:"user_asset_id"`
+	LatencyMS   float64   `json:"latency_ms"`
+}
+
+// Export writes the records of the given items (every item when empty) observed since the given time.
+// CSV gets a header row, JSON is a single array.
+func Export(w io.Writer, dir string, ids []string, format string, since time.Time) (int, error) {
+	if len(ids) == 0 {
+		var err error
+		if ids, err = Items(dir); err != nil {
+			return 0, err
+		}
+	}
+
+	var write func(exported) error
+	var finish func() error
+
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		out := csv.NewWriter(w)
+		if err := out.Write([]string{"time", "limited_id", "price", "seller_id", "user_asset_id", "latency_ms"}); err != nil {
+			return 0, err
+		}
+		write = func(e exported) error {
+			return out.Write([]string{
+				e.Time.Format(time.RFC3339Nano),
+				e.LimitedID,
+				strconv.Itoa(e.Price),
+				strconv.Itoa(e.SellerID),
+				strconv.Itoa(e.UserAssetID),
+				strconv.FormatFloat(e.LatencyMS, 'f', 3, 64),
+			})
+		}
+		finish = func() error {
+			out.Flush()
+			return out.Error()
+		}
+	case FormatJSON:
+		out := bufio.NewWriter(w)
+		first := true
+		out.WriteString("[")
+		write = func(e exported) error {
+			line, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if !first {
+				out.WriteString(",")
+			}
+			first = false
+			out.WriteString("\n  ")
+			_, err = out.Write(line)
+			return err
+		}
+		finish = func() error {
+			out.WriteString("\n]\n")
+			return out.Flush()
+		}
+	default:
+		return 0, fmt.Errorf("unknown export format %q, use %s or %s", format, FormatCSV, FormatJSON)
+	}
+
+	count := 0
+	for _, id := range ids {
+		err := Read(dir, id, func(r Record) error {
+			if r.Time.Before(since) {
+				return nil
+			}
+			count++
+			return write(exported{
+				Time:        r.Time,
+				LimitedID:   r.LimitedID,
+				Price:       r.Price,
+				SellerID:    r.SellerID,
+				UserAssetID: r.UserAssetID,
+				LatencyMS:   float64(r.Latency) / float64(time.Millisecond),
+			})
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, finish()
+}
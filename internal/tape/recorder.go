@@ -0,0 +1,198 @@
+package tape
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sniper/internal/config"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sort"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("tape")
+
+type itemFile struct {
+	file *os.File
+	size int64
+}
+
+var (
+	directory string
+	maxSize   int64
+	maxFiles  int
+	files     = map[string]*itemFile{}
+	mu        sync.Mutex
+)
+
+// Start records every observed listing from the event bus, an empty directory disables the tape.
+func Start(cfg config.TapeConfig) error {
+	if cfg.Directory == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(cfg.Directory, 0o755); err != nil {
+		return fmt.Errorf("failed to create tape directory: %w", err)
+	}
+
+	mu.Lock()
+	directory = cfg.Directory
+	maxSize = int64(cfg.MaxSizeMB) * 1024 * 1024
+	maxFiles = cfg.MaxFiles
+	mu.Unlock()
+
+	// Every poll is recorded, a stalled disk queues them instead of leaving gaps the backtest
+	// would count as missed polls
+	events.SubscribeLossless("tape", func(event events.Event) {
+		observed := event.(events.PriceObserved)
+
+		// UGC listings have no user asset id, the serial number tells their copies apart instead
+		copyID := observed.UserAssetID
+		if copyID == 0 {
+			copyID = observed.SerialNumber
+		}
+
+		err := Append(Record{
+			Time:        observed.Time,
+			LimitedID:   observed.LimitedID,
+			Price:       observed.Price,
+			SellerID:    observed.SellerID,
+			UserAssetID: copyID,
+			Latency:     observed.Latency,
+		})
+		if err != nil {
+			logger.Error("Could not record listing", "Limited ID", observed.LimitedID, "Error", err)
+		}
+	}, events.KindPriceObserved)
+
+	return nil
+}
+
+// Append writes a record to the item's tape, rotating the file once it is full.
+func Append(r Record) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if directory == "" {
+		return nil
+	}
+
+	f, err := open(r.LimitedID)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, recordSize)
+	encode(buf, r)
+	if _, err := f.file.Write(buf); err != nil {
+		return fmt.Errorf("failed to write tape: %w", err)
+	}
+	f.size += recordSize
+
+	if maxSize > 0 && f.size >= maxSize {
+		return rotate(r.LimitedID)
+	}
+	return nil
+}
+
+// Close flushes and closes every open tape file.
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for id, f := range files {
+		f.file.Close()
+		delete(files, id)
+	}
+}
+
+func currentPath(dir, limitedID string) string {
+	return filepath.Join(dir, filepath.Base(limitedID)+extension)
+}
+
+// open returns the current file of an item, creating it with a header when needed.
+// A partial record left by a crash is cut off so the file stays aligned.
+func open(limitedID string) (*itemFile, error) {
+	if f, ok := files[limitedID]; ok {
+		return f, nil
+	}
+
+	path := currentPath(directory, limitedID)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tape: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open tape: %w", err)
+	}
+
+	size := info.Size()
+	if size < int64(headerSize) {
+		if err := file.Truncate(0); err == nil {
+			_, err = file.WriteAt([]byte(magic), 0)
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write tape header: %w", err)
+		}
+		size = int64(headerSize)
+	} else if extra := (size - int64(headerSize)) % recordSize; extra != 0 {
+		logger.Warn("Tape ends with a partial record, cutting it off.", "File", path)
+		size -= extra
+		if err := file.Truncate(size); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to repair tape: %w", err)
+		}
+	}
+
+	if _, err := file.Seek(size, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open tape: %w", err)
+	}
+
+	f := &itemFile{file: file, size: size}
+	files[limitedID] = f
+	return f, nil
+}
+
+// rotate renames the current file to <id>.<timestamp>.tape and drops the oldest rotated files.
+func rotate(limitedID string) error {
+	if f, ok := files[limitedID]; ok {
+		f.file.Close()
+		delete(files, limitedID)
+	}
+
+	current := currentPath(directory, limitedID)
+	rotated := filepath.Join(directory, fmt.Sprintf("%s.%s%s", filepath.Base(limitedID), time.Now().Format("20060102-150405.000"), extension))
+	if err := os.Rename(current, rotated); err != nil {
+		return fmt.Errorf("failed to rotate tape: %w", err)
+	}
+
+	old, err := rotatedFiles(directory, limitedID)
+	if err != nil {
+		return err
+	}
+	for len(old) > maxFiles {
+		if err := os.Remove(old[0]); err != nil {
+			return fmt.Errorf("failed to remove old tape: %w", err)
+		}
+		old = old[1:]
+	}
+
+	return nil
+}
+
+// rotatedFiles lists the rotated files of an item, oldest first.
+func rotatedFiles(dir, limitedID string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, filepath.Base(limitedID)+".*"+extension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
@@ -0,0 +1,92 @@
+package tape
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Tape files start with a magic header, followed by fixed size little endian records:
+//
+//	int64  observed at, unix nanoseconds
+//	int32  lowest price
+//	int64  seller id
+//	int64  user asset id
+//	uint32 fetch latency, microseconds
+//
+// The limited id is the file name, so it is not repeated in every record.
+const (
+	magic      = "SNPTAPE\x01"
+	headerSize = len(magic)
+	recordSize = 8 + 4 + 8 + 8 + 4
+	extension  = ".tape"
+)
+
+var ErrNotTape = errors.New("not a tape file")
+
+// Record is a single observed listing.
+type Record struct {
+	Time        time.Time
+	LimitedID   string
+	Price       int
+	SellerID    int
+	UserAssetID int // Serial number for UGC limiteds, which have no user asset id
+	Latency     time.Duration
+}
+
+func encode(buf []byte, r Record) {
+	latency := r.Latency.Microseconds()
+	if latency < 0 {
+		latency = 0
+	} else if latency > int64(^uint32(0)) {
+		latency = int64(^uint32(0))
+	}
+
+	binary.LittleEndian.PutUint64(buf[0:], uint64(r.Time.UnixNano()))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(int32(r.Price)))
+	binary.LittleEndian.PutUint64(buf[12:], uint64(r.SellerID))
+	binary.LittleEndian.PutUint64(buf[20:], uint64(r.UserAssetID))
+	binary.LittleEndian.PutUint32(buf[28:], uint32(latency))
+}
+
+func decode(buf []byte, limitedID string) Record {
+	return Record{
+		Time:        time.Unix(0, int64(binary.LittleEndian.Uint64(buf[0:]))),
+		LimitedID:   limitedID,
+		Price:       int(int32(binary.LittleEndian.Uint32(buf[8:]))),
+		SellerID:    int(int64(binary.LittleEndian.Uint64(buf[12:]))),
+		UserAssetID: int(int64(binary.LittleEndian.Uint64(buf[20:]))),
+		Latency:     time.Duration(binary.LittleEndian.Uint32(buf[28:])) * time.Microsecond,
+	}
+}
+
+// readRecords calls fn for every record of a tape stream.
+// A partial record at the end (a crash mid-write) is ignored.
+func readRecords(r io.Reader, limitedID string, fn func(Record) error) error {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("%w: %v", ErrNotTape, err)
+	}
+	if string(header) != magic {
+		return ErrNotTape
+	}
+
+	buf := make([]byte, recordSize)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err := fn(decode(buf, limitedID)); err != nil {
+			return err
+		}
+	}
+}
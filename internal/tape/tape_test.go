@@ -0,0 +1,101 @@
+package tape
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 19, 12, 0, 0, 123456789, time.UTC)
+
+	records := []Record{
+		{Time: at, LimitedID: "1234", Price: 500, SellerID: 77, UserAssetID: 9001, Latency: 85 * time.Millisecond},
+		{Time: at.Add(time.Second), LimitedID: "1234", Price: 0, Latency: 0},
+		{Time: at.Add(2 * time.Second), LimitedID: "1234", Price: -1, SellerID: 1 << 40, UserAssetID: 1 << 50, Latency: time.Microsecond},
+	}
+
+	var tape bytes.Buffer
+	tape.WriteString(magic)
+	for _, r := range records {
+		buf := make([]byte, recordSize)
+		encode(buf, r)
+		tape.Write(buf)
+	}
+
+	var got []Record
+	err := readRecords(&tape, "1234", func(r Record) error {
+		got = append(got, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(records) {
+		t.Fatalf("read %d records, want %d", len(got), len(records))
+	}
+	for i, want := range records {
+		if !got[i].Time.Equal(want.Time) {
+			t.Errorf("record %d: time %v, want %v", i, got[i].Time, want.Time)
+		}
+		got[i].Time = want.Time
+		if got[i] != want {
+			t.Errorf("record %d: got %+v, want %+v", i, got[i], want)
+		}
+	}
+}
+
+func TestLatencyIsClamped(t *testing.T) {
+	tests := []struct {
+		latency time.Duration
+		want    time.Duration
+	}{
+		{-time.Second, 0},
+		{1500 * time.Nanosecond, time.Microsecond},
+		{time.Duration(1<<32) * time.Microsecond, time.Duration(1<<32-1) * time.Microsecond},
+	}
+
+	for _, tt := range tests {
+		buf := make([]byte, recordSize)
+		encode(buf, Record{Latency: tt.latency})
+		if got := decode(buf, "1234").Latency; got != tt.want {
+			t.Errorf("latency %v decoded as %v, want %v", tt.latency, got, tt.want)
+		}
+	}
+}
+
+func TestReadRecords(t *testing.T) {
+	full := make([]byte, recordSize)
+	encode(full, Record{Time: time.Unix(1, 0), Price: 500})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		records int
+		err     error
+	}{
+		{"empty file", nil, 0, nil},
+		{"header only", []byte(magic), 0, nil},
+		{"partial record after a crash", append(append([]byte(magic), full...), full[:10]...), 1, nil},
+		{"wrong magic", append([]byte("NOTATAPE"), full...), 0, ErrNotTape},
+		{"short header", []byte("SNP"), 0, ErrNotTape},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := 0
+			err := readRecords(bytes.NewReader(tt.data), "1234", func(Record) error {
+				records++
+				return nil
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if records != tt.records {
+				t.Errorf("read %d records, want %d", records, tt.records)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"sniper/internal/events"
+	"sniper/internal/parser"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	feedSize = 50  // Purchases kept for the feed
+	logSize  = 200 // Log lines kept for the log pane
+)
+
+// item is a row of the watchlist table.
+type item struct {
+	ID       string
+	Name     string
+	Source   string
+	Target   int
+	Price    int // Lowest price of the last poll, -1 before the first one
+	Latency  time.Duration
+	Polls    int
+	Errors   int
+	State    string
+	Reason   string
+	LastPoll time.Time
+}
+
+// purchase is a line of the purchase feed.
+type purchase struct {
+	Time    time.Time
+	ID      string
+	Name    string
+	Price   int
+	Target  int
+	Bought  bool
+	Message string
+}
+
+var (
+	items     = map[string]*item{}
+	itemOrder []string
+	feed      []purchase
+	scrapes   int // Failed polls since startup
+	failures  int // Failed purchases since startup
+	bought    int
+	spent     int
+	lines     []string
+	stateMu   sync.Mutex
+)
+
+// Start collects what the dashboard shows from the event bus, it must run before the workers
+// start so their first state change is not missed.
+func Start() {
+	events.Subscribe("tui", 0, func(event events.Event) {
+		stateMu.Lock()
+		defer stateMu.Unlock()
+
+		id := event.Meta().LimitedID
+		switch e := event.(type) {
+		case events.PriceObserved:
+			row := rowLocked(id)
+			row.Price = e.Price
+			row.Target = e.Target
+			row.Latency = e.Latency
+			row.LastPoll = e.Time
+			row.Polls++
+		case events.ScrapeFailed:
+			row := rowLocked(id)
+			row.Errors++
+			row.Polls++
+			scrapes++
+		case events.WorkerStateChanged:
+			row := rowLocked(id)
+			row.State = e.State
+			row.Reason = e.Reason
+			if e.ItemName != "" {
+				row.Name = e.ItemName
+			}
+			if e.Source != "" {
+				row.Source = e.Source
+			}
+			if e.Target > 0 {
+				row.Target = e.Target
+			}
+		case events.PurchaseSucceeded:
+			bought++
+			spent += e.Price
+			record(purchase{Time: e.Time, ID: id, Name: e.ItemName, Price: e.Price, Target: e.Target, Bought: true})
+		case events.PurchaseFailed:
+			failures++
+			record(purchase{Time: e.Time, ID: id, Name: e.ItemName, Price: e.Price, Target: e.Target, Message: e.Message})
+		}
+	}, events.KindPriceObserved, events.KindScrapeFailed, events.KindWorkerStateChanged, events.KindPurchaseSucceeded, events.KindPurchaseFailed)
+}
+
+// track adds rows for the watched items that did not publish anything yet.
+func track(limiteds []parser.LimitedInfo) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	for _, limited := range limiteds {
+		row := rowLocked(limited.Id)
+		if row.Target == 0 {
+			row.Target = limited.Price
+		}
+		if row.Source == "" {
+			row.Source = limited.Source
+		}
+	}
+}
+
+// rowLocked returns the row of an item, adding it when new. Callers hold stateMu.
+func rowLocked(id string) *item {
+	row, ok := items[id]
+	if !ok {
+		row = &item{ID: id, Price: -1, State: "starting"}
+		items[id] = row
+		itemOrder = append(itemOrder, id)
+	}
+	return row
+}
+
+func record(p purchase) {
+	feed = append(feed, p)
+	if len(feed) > feedSize {
+		feed = feed[len(feed)-feedSize:]
+	}
+}
+
+// snapshot is a copy of the collected state for rendering.
+type snapshot struct {
+	Items    []item
+	Feed     []purchase
+	Scrapes  int
+	Failures int
+	Bought   int
+	Spent    int
+	Lines    []string
+}
+
+func takeSnapshot() snapshot {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	s := snapshot{
+		Items:    make([]item, 0, len(itemOrder)),
+		Feed:     append([]purchase(nil), feed...),
+		Scrapes:  scrapes,
+		Failures: failures,
+		Bought:   bought,
+		Spent:    spent,
+		Lines:    append([]string(nil), lines...),
+	}
+	for _, id := range itemOrder {
+		s.Items = append(s.Items, *items[id])
+	}
+	return s
+}
+
+// logWriter keeps the latest log lines for the log pane instead of writing them to the terminal.
+type logWriter struct {
+	partial []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, strings.TrimRight(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	if len(lines) > logSize {
+		lines = lines[len(lines)-logSize:]
+	}
+	return len(p), nil
+}
+
+// distance describes how far the last price is above the target.
+func distance(row item) string {
+	if row.Price < 0 || row.Target <= 0 {
+		return "-"
+	}
+	diff := row.Price - row.Target
+	return fmt.Sprintf("%+d (%+.0f%%)", diff, float64(diff)/float64(row.Target)*100)
+}
@@ -0,0 +1,294 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sniper/internal/approval"
+	"sniper/internal/balance"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/worker"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const refreshInterval = 250 * time.Millisecond
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#42b3f5"))
+	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("245"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	goodStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#3ba55d"))
+	badStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#e03b3b"))
+	warnStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#f5a742"))
+)
+
+type tickMsg time.Time
+
+type model struct {
+	width, height int
+	selected      int
+	snap          snapshot
+	notice        string
+}
+
+// Run takes over the terminal with the dashboard until q or ctrl+c is pressed. Log lines are
+// shown in its log pane while it runs and go back to stderr afterwards.
+func Run() error {
+	logging.SetOutput(&logWriter{})
+	defer logging.SetOutput(os.Stderr)
+
+	m := model{}
+	m.refresh()
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func (m *model) refresh() {
+	track(worker.Watched())
+	m.snap = takeSnapshot()
+	if m.selected >= len(m.snap.Items) {
+		m.selected = max(0, len(m.snap.Items)-1)
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tickMsg:
+		m.refresh()
+		return m, tick()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.selected = max(0, m.selected-1)
+		case "down", "j":
+			m.selected = min(len(m.snap.Items)-1, m.selected+1)
+		case "p", " ":
+			m.toggle()
+		case "a", "d":
+			m.decide(msg.String() == "a")
+		case "v":
+			logging.SetVerbose(!logging.Verbose())
+			m.notice = "verbose output off"
+			if logging.Verbose() {
+				m.notice = "verbose output on"
+			}
+		}
+	}
+	return m, nil
+}
+
+// toggle pauses or resumes the selected item.
+func (m *model) toggle() {
+	if m.selected < 0 || m.selected >= len(m.snap.Items) {
+		return
+	}
+
+	id := m.snap.Items[m.selected].ID
+	switch {
+	case worker.Held(id) && worker.Resume(id):
+		m.notice = fmt.Sprintf("resumed %s", id)
+	case worker.Pause(id):
+		m.notice = fmt.Sprintf("paused %s", id)
+	default:
+		m.notice = fmt.Sprintf("%s is not watched", id)
+	}
+}
+
+// decide approves or denies the oldest purchase waiting for approval.
+func (m *model) decide(approve bool) {
+	pending := approval.Pending()
+	if len(pending) == 0 {
+		m.notice = "no purchase is waiting for approval"
+		return
+	}
+
+	id := pending[0].ID
+	decide, verb := approval.Deny, "denied"
+	if approve {
+		decide, verb = approval.Approve, "approved"
+	}
+	if err := decide(id); err != nil {
+		m.notice = err.Error()
+		return
+	}
+	m.notice = fmt.Sprintf("%s %s for %s", verb, id, pending[0].LimitedID)
+}
+
+func (m model) View() string {
+	var b strings.Builder
+
+	robux, known := balance.Get()
+	balanceText := "unknown"
+	if known {
+		balanceText = fmt.Sprintf("R$ %d (spendable R$ %d)", robux, balance.Spendable())
+	}
+
+	var dropped int64
+	for _, count := range events.Dropped() {
+		dropped += count
+	}
+
+	b.WriteString(titleStyle.Render("Limited Sniper"))
+	b.WriteString(fmt.Sprintf("  Balance %s  Bought %d for R$ %d  ", balanceText, m.snap.Bought, m.snap.Spent))
+	b.WriteString(errorCount("Scrape errors", m.snap.Scrapes))
+	b.WriteString("  ")
+	b.WriteString(errorCount("Failed purchases", m.snap.Failures))
+	b.WriteString("  ")
+	b.WriteString(errorCount("Dropped events", int(dropped)))
+	b.WriteString("\n")
+
+	if pending := approval.Pending(); len(pending) > 0 {
+		r := pending[0]
+		b.WriteString(warnStyle.Render(fmt.Sprintf("Waiting for approval (%d): %s for R$ %d, %s, expires %s. Press a to approve or d to deny.",
+			len(pending), r.LimitedID, r.Listing.Price, r.Reason, r.Expires.Local().Format("15:04:05"))))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(m.table())
+	b.WriteString("\n")
+
+	// The feed and log pane share what is left of the screen
+	rest := m.height - len(m.snap.Items) - 8
+	feedRows := max(3, min(len(m.snap.Feed), rest/2))
+	logRows := max(3, rest-feedRows-2)
+
+	b.WriteString(headerStyle.Render("PURCHASES"))
+	b.WriteString("\n")
+	if len(m.snap.Feed) == 0 {
+		b.WriteString(dimStyle.Render("nothing bought yet"))
+		b.WriteString("\n")
+	}
+	for i := len(m.snap.Feed) - 1; i >= 0 && i >= len(m.snap.Feed)-feedRows; i-- {
+		b.WriteString(feedLine(m.snap.Feed[i]))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(headerStyle.Render("LOG"))
+	b.WriteString("\n")
+	start := max(0, len(m.snap.Lines)-logRows)
+	for _, line := range m.snap.Lines[start:] {
+		b.WriteString(m.clip(line))
+		b.WriteString("\n")
+	}
+
+	help := "↑/↓ select  p pause/resume  a/d approve/deny  v verbose  q quit"
+	if m.notice != "" {
+		help += "  · " + m.notice
+	}
+	b.WriteString(dimStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) table() string {
+	var b strings.Builder
+
+	header := fmt.Sprintf("%-28s %8s %8s %16s %9s %7s %6s  %s", "ITEM", "TARGET", "PRICE", "DISTANCE", "LATENCY", "POLLS", "ERRORS", "STATE")
+	b.WriteString(headerStyle.Render(m.clip(header)))
+	b.WriteString("\n")
+
+	for i, row := range m.snap.Items {
+		name := row.ID
+		if row.Name != "" {
+			name = fmt.Sprintf("%s (%s)", row.Name, row.ID)
+		}
+		if row.Source != "" {
+			name = row.Source + ": " + name
+		}
+
+		price := "-"
+		if row.Price >= 0 {
+			price = fmt.Sprintf("%d", row.Price)
+		}
+		latency := "-"
+		if row.Latency > 0 {
+			latency = row.Latency.Round(time.Millisecond).String()
+		}
+
+		state := row.State
+		if row.Reason != "" {
+			state += ": " + row.Reason
+		}
+
+		line := m.clip(fmt.Sprintf("%-28s %8d %8s %16s %9s %7d %6d  %s", truncate(name, 28), row.Target, price, distance(row), latency, row.Polls, row.Errors, state))
+		switch {
+		case i == m.selected:
+			line = selectedStyle.Render(line)
+		case row.State == events.StateStopped:
+			line = dimStyle.Render(line)
+		case row.State == events.StatePaused:
+			line = warnStyle.Render(line)
+		case row.Price >= 0 && row.Price <= row.Target:
+			line = goodStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	if len(m.snap.Items) == 0 {
+		b.WriteString(dimStyle.Render("no items watched"))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func feedLine(p purchase) string {
+	name := p.ID
+	if p.Name != "" {
+		name = p.Name
+	}
+
+	line := fmt.Sprintf("%s  %-28s R$ %d (target %d)", p.Time.Local().Format("15:04:05"), truncate(name, 28), p.Price, p.Target)
+	if p.Bought {
+		return goodStyle.Render(line + "  bought")
+	}
+	return badStyle.Render(line + "  failed: " + p.Message)
+}
+
+func errorCount(label string, count int) string {
+	text := fmt.Sprintf("%s %d", label, count)
+	if count > 0 {
+		return badStyle.Render(text)
+	}
+	return text
+}
+
+// clip cuts a line to the terminal width so it never wraps.
+func (m model) clip(line string) string {
+	if m.width <= 0 {
+		return line
+	}
+	return truncate(line, m.width)
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
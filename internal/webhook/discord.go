@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+)
+
+type Embed struct {
+	Color       int            `json:"color"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	URL         string         `json:"url,omitempty"`
+	Thumbnail   EmbedThumbnail `json:"thumbnail"`
+}
+
+type EmbedThumbnail struct {
+	URL string `json:"url"`
+}
+
+type WebhookPayload struct {
+	Content string  `json:"content,omitempty"`
+	Embeds  []Embed `json:"embeds"`
+}
+
+// DiscordNotifier posts messages as a single embed to a Discord webhook.
+type DiscordNotifier struct {
+	URL string
+}
+
+func (d *DiscordNotifier) Name() string {
+	return "discord"
+}
+
+func (d *DiscordNotifier) Notify(msg Message) error {
+	payload := WebhookPayload{
+		Embeds: []Embed{{
+			Color:       msg.Color,
+			Title:       msg.Title,
+			Description: msg.Description,
+			URL:         msg.URL,
+			Thumbnail:   EmbedThumbnail{URL: msg.ThumbnailURL},
+		}},
+	}
+
+	if msg.Urgent {
+		payload.Content = "@everyone"
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discord payload: %w", err)
+	}
+
+	return post(d.Name(), d.URL, "application/json", payloadJSON, nil)
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type JSONPayload struct {
+	Message
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JSONNotifier posts the raw message as JSON, meant for in-house tooling.
+type JSONNotifier struct {
+	URL     string
+	Headers map[string]string
+}
+
+func (j *JSONNotifier) Name() string {
+	return "json"
+}
+
+func (j *JSONNotifier) Notify(msg Message) error {
+	payloadJSON, err := json.Marshal(JSONPayload{
+		Message:   msg,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal json payload: %w", err)
+	}
+
+	return post(j.Name(), j.URL, "application/json", payloadJSON, j.Headers)
+}
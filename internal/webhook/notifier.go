@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sniper/internal/config"
+	"strings"
+)
+
+// EventKind names the situation a notification is about, sinks filter on it.
+type EventKind string
+
+const (
+	EventSuccess         EventKind = "success"
+	EventFailure         EventKind = "failure"
+	EventError           EventKind = "error"
+	EventStartup         EventKind = "startup"
+	EventBudgetExhausted EventKind = "budget_exhausted"
+	EventDigest          EventKind = "digest"
+	EventSessionLost     EventKind = "session_lost"
+	EventResale          EventKind = "resale"
+	EventSale            EventKind = "sale"
+	EventDiscovery       EventKind = "discovery"
+	EventAlert           EventKind = "alert"
+)
+
+// EventKinds lists every event a sink can subscribe to.
+var EventKinds = []EventKind{
+	EventSuccess,
+	EventFailure,
+	EventError,
+	EventStartup,
+	EventBudgetExhausted,
+	EventDigest,
+	EventSessionLost,
+	EventResale,
+	EventSale,
+	EventDiscovery,
+	EventAlert,
+}
+
+// Message is the sink-agnostic notification, each Notifier renders it in its own format.
+type Message struct {
+	Event        EventKind `json:"event"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	URL          string    `json:"url,omitempty"`
+	Color        int       `json:"color"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	Urgent       bool      `json:"urgent,omitempty"`  // Pings everyone on sinks that support it
+	Attempt      string    `json:"attempt,omitempty"` // Correlation id of the snipe attempt, if any
+}
+
+// Notifier delivers a message to a single destination.
+type Notifier interface {
+	Name() string
+	Notify(msg Message) error
+}
+
+// route pairs a notifier with the events it has subscribed to, a nil filter accepts everything.
+// key identifies the sink across restarts so persisted messages find their way back.
+type route struct {
+	key      string
+	notifier Notifier
+	events   map[EventKind]bool
+}
+
+func (r route) accepts(event EventKind) bool {
+	return r.events == nil || r.events[event]
+}
+
+// NewNotifier builds the notifier for a single sink entry of the config file.
+func NewNotifier(sink config.WebhookSink) (Notifier, error) {
+	switch strings.ToLower(sink.Type) {
+	case "", "discord":
+		return &DiscordNotifier{URL: sink.URL}, nil
+	case "slack":
+		return &SlackNotifier{URL: sink.URL}, nil
+	case "telegram":
+		return &TelegramNotifier{URL: sink.URL, Token: sink.Token, ChatID: sink.ChatID}, nil
+	case "json":
+		return &JSONNotifier{URL: sink.URL, Headers: sink.Headers}, nil
+	case "ntfy":
+		return &NtfyNotifier{URL: sink.URL, Token: sink.Token}, nil
+	default:
+		return nil, fmt.Errorf("unknown webhook type %q", sink.Type)
+	}
+}
+
+// parseEvents turns the configured event names into a filter, empty means all events.
+func parseEvents(names []string) (map[EventKind]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	events := make(map[EventKind]bool, len(names))
+	for _, name := range names {
+		event := EventKind(strings.ToLower(strings.TrimSpace(name)))
+		known := false
+		for _, kind := range EventKinds {
+			if kind == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown webhook event %q", name)
+		}
+		events[event] = true
+	}
+
+	return events, nil
+}
+
+// Init builds every configured sink and starts their delivery queues.
+func Init(cfg *config.ConfigStruct) error {
+	if err := loadTemplates(cfg.Templates); err != nil {
+		return err
+	}
+
+	built, err := buildRoutes(cfg)
+	if err != nil {
+		return err
+	}
+
+	return startQueue(cfg.NotifyQueue, built)
+}
+
+// Check sends the message to every configured sink right away, without starting the queue,
+// and returns one error per sink that failed.
+func Check(cfg *config.ConfigStruct, msg Message) []error {
+	if err := loadTemplates(cfg.Templates); err != nil {
+		return []error{err}
+	}
+
+	built, err := buildRoutes(cfg)
+	if err != nil {
+		return []error{err}
+	}
+
+	if len(built) == 0 {
+		return []error{fmt.Errorf("no webhook configured")}
+	}
+
+	var errs []error
+	for _, r := range built {
+		if err := r.notifier.Notify(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// buildRoutes turns the config into sinks, the legacy webhook_url is kept as a Discord sink for all events.
+func buildRoutes(cfg *config.ConfigStruct) ([]route, error) {
+	var built []route
+
+	if cfg.WebhookURL != "" {
+		built = append(built, route{
+			key:      routeKey("discord", cfg.WebhookURL),
+			notifier: &DiscordNotifier{URL: cfg.WebhookURL},
+		})
+	}
+
+	for i, sink := range cfg.Webhooks {
+		notifier, err := NewNotifier(sink)
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+
+		events, err := parseEvents(sink.Events)
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+
+		built = append(built, route{
+			key:      routeKey(notifier.Name(), sink.URL, sink.ChatID),
+			notifier: notifier,
+			events:   events,
+		})
+	}
+
+	return built, nil
+}
+
+// routeKey identifies a sink across reloads and in the spool file. Webhook URLs and bot tokens are
+// credentials, so only their hash is kept.
+func routeKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:16])
+}
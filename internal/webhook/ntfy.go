@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"strings"
+)
+
+// ntfy priorities, failures are more interesting than routine events.
+var ntfyPriorities = map[EventKind]string{
+	EventSuccess:         "high",
+	EventFailure:         "default",
+	EventError:           "default",
+	EventStartup:         "low",
+	EventBudgetExhausted: "high",
+	EventDigest:          "low",
+	EventSessionLost:     "high",
+	EventResale:          "default",
+	EventSale:            "high",
+	EventDiscovery:       "default",
+	EventAlert:           "high",
+}
+
+// NtfyNotifier publishes messages as plain text to an ntfy-style topic URL.
+type NtfyNotifier struct {
+	URL   string
+	Token string
+}
+
+func (n *NtfyNotifier) Name() string {
+	return "ntfy"
+}
+
+func (n *NtfyNotifier) Notify(msg Message) error {
+	headers := map[string]string{
+		"Title": msg.Title,
+		"Tags":  string(msg.Event),
+	}
+	if priority, ok := ntfyPriorities[msg.Event]; ok {
+		headers["Priority"] = priority
+	}
+	if msg.Urgent {
+		headers["Priority"] = "urgent"
+	}
+	if msg.URL != "" {
+		headers["Click"] = msg.URL
+	}
+	if msg.ThumbnailURL != "" {
+		headers["Icon"] = msg.ThumbnailURL
+	}
+	if n.Token != "" {
+		headers["Authorization"] = "Bearer " + n.Token
+	}
+
+	body := strings.ReplaceAll(msg.Description, "`", "")
+	return post(n.Name(), n.URL, "text/plain; charset=utf-8", []byte(body), headers)
+}
@@ -0,0 +1,367 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sniper/internal/config"
+	"sniper/internal/logging"
+	"sniper/internal/redact"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var logger = logging.For("webhook")
+
+const (
+	DefaultQueueSize  = 100
+	DefaultMaxRetries = 5
+	DefaultSpoolPath  = "notifications.json"
+
+	baseBackoff = 2 * time.Second
+	maxBackoff  = 5 * time.Minute
+
+	// Changes to the queue within this window are saved to the spool file in one write
+	spoolDelay = time.Second
+)
+
+// delivery is one message waiting to be sent to one sink, it is what ends up in the spool file.
+type delivery struct {
+	Sink        string    `json:"sink"` // Hash of the sink, see routeKey
+	Message     Message   `json:"message"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// sender owns the pending deliveries of a single sink, so one rate-limited sink never holds up another.
+type sender struct {
+	route   route
+	mu      sync.Mutex
+	pending []*delivery
+	wake    chan struct{}
+	done    chan struct{} // Closed once run has returned
+}
+
+var (
+	senders    []*sender
+	sendersMu  sync.RWMutex
+	queueSize  = DefaultQueueSize
+	maxRetries = DefaultMaxRetries
+	spoolPath  = DefaultSpoolPath
+	spoolMu    sync.Mutex
+	spoolDirty = make(chan struct{}, 1)
+	spoolOnce  sync.Once
+	quit       chan struct{}
+)
+
+// startQueue replaces the running senders and re-queues whatever was left in the spool file.
+func startQueue(cfg config.NotifyQueueConfig, built []route) error {
+	sendersMu.Lock()
+	defer sendersMu.Unlock()
+
+	spoolOnce.Do(func() { go saveSpool() })
+
+	// Let the old senders finish the delivery they are sending, a message that went out is
+	// removed from the queue before it is carried over and never sent twice
+	if quit != nil {
+		close(quit)
+	}
+	for _, s := range senders {
+		<-s.done
+	}
+	quit = make(chan struct{})
+
+	queueSize = DefaultQueueSize
+	if cfg.Size > 0 {
+		queueSize = cfg.Size
+	}
+	maxRetries = DefaultMaxRetries
+	if cfg.MaxRetries > 0 {
+		maxRetries = cfg.MaxRetries
+	}
+	spoolPath = DefaultSpoolPath
+	if cfg.SpoolPath != "" {
+		spoolPath = cfg.SpoolPath
+	}
+
+	// Keep deliveries that are still in memory when the sinks are rebuilt
+	carried := map[string][]*delivery{}
+	for _, s := range senders {
+		s.mu.Lock()
+		carried[s.route.key] = append(carried[s.route.key], s.pending...)
+		s.mu.Unlock()
+	}
+
+	if len(senders) == 0 {
+		spooled, err := readSpool()
+		if err != nil {
+			return err
+		}
+		for _, d := range spooled {
+			// Older spools keyed sinks by their URL, rewritten as the hash on the next save
+			if strings.Contains(d.Sink, "|") {
+				d.Sink = routeKey(d.Sink)
+			}
+			carried[d.Sink] = append(carried[d.Sink], d)
+		}
+	}
+
+	senders = make([]*sender, 0, len(built))
+	for _, r := range built {
+		s := &sender{
+			route:   r,
+			pending: carried[r.key],
+			wake:    make(chan struct{}, 1),
+			done:    make(chan struct{}),
+		}
+		delete(carried, r.key)
+		senders = append(senders, s)
+		go s.run(quit)
+	}
+
+	for _, lost := range carried {
+		logger.Warn("Dropping queued notifications for a webhook that is no longer configured.", "Count", len(lost))
+	}
+
+	if restored := pendingCount(); restored > 0 {
+		logger.Info("Restored queued notifications", "Count", restored)
+	}
+
+	return writeSpool()
+}
+
+// Notify queues the message for every sink that subscribed to its event, it never blocks on the network.
+func Notify(msg Message) {
+	sendersMu.RLock()
+	defer sendersMu.RUnlock()
+
+	if len(senders) == 0 {
+		logger.Warn("No webhook configured, dropping notification.", "Event", msg.Event)
+		return
+	}
+
+	// Descriptions carry error messages, keep secrets out of chat channels and the spool file
+	msg.Title = redact.String(msg.Title)
+	msg.Description = redact.String(msg.Description)
+
+	for _, s := range senders {
+		if s.route.accepts(msg.Event) {
+			s.enqueue(&delivery{Sink: s.route.key, Message: msg})
+		}
+	}
+
+	persist()
+}
+
+// persist has the queue saved to the spool file shortly, changes made in the meantime are
+// saved with it.
+func persist() {
+	select {
+	case spoolDirty <- struct{}{}:
+	default:
+	}
+}
+
+// saveSpool writes the spool file once per batch of changes.
+func saveSpool() {
+	for range spoolDirty {
+		time.Sleep(spoolDelay)
+
+		sendersMu.RLock()
+		err := writeSpool()
+		sendersMu.RUnlock()
+
+		if err != nil {
+			logger.Error("Failed to persist notification queue", "Error", err)
+		}
+	}
+}
+
+func (s *sender) enqueue(d *delivery) {
+	s.mu.Lock()
+	if len(s.pending) >= queueSize {
+		logger.Warn("Notification queue is full, dropping the oldest message.", "Sink", s.route.notifier.Name(), "Title", s.pending[0].Message.Title)
+		s.pending = s.pending[1:]
+	}
+	s.pending = append(s.pending, d)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *sender) run(quit <-chan struct{}) {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		s.mu.Lock()
+		var next *delivery
+		if len(s.pending) > 0 {
+			next = s.pending[0]
+		}
+		s.mu.Unlock()
+
+		if next == nil {
+			select {
+			case <-quit:
+				return
+			case <-s.wake:
+			}
+			continue
+		}
+
+		if wait := time.Until(next.NextAttempt); wait > 0 {
+			select {
+			case <-quit:
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		l := logger
+		if next.Message.Attempt != "" {
+			l = logging.Attempt("webhook", next.Message.Attempt)
+		}
+
+		err := s.route.notifier.Notify(next.Message)
+		if err == nil {
+			l.Info("Webhook sent successfully", "Sink", s.route.notifier.Name(), "Event", next.Message.Event)
+			s.remove(next)
+		} else {
+			s.mu.Lock()
+			next.Attempts++
+			attempts := next.Attempts
+			s.mu.Unlock()
+
+			if !retryable(err) || attempts > maxRetries {
+				l.Error("Giving up on webhook:", "Sink", s.route.notifier.Name(), "Attempts", attempts, "Error", err)
+				s.remove(next)
+			} else {
+				delay := backoff(attempts, err)
+				s.mu.Lock()
+				next.NextAttempt = time.Now().Add(delay)
+				s.mu.Unlock()
+				l.Warn("Webhook failed, retrying.", "Sink", s.route.notifier.Name(), "In", delay, "Error", err)
+			}
+		}
+
+		persist()
+	}
+}
+
+func (s *sender) remove(d *delivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, pending := range s.pending {
+		if pending == d {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// retryable reports whether sending again can succeed, a bad URL or payload never will.
+func retryable(err error) bool {
+	var status *StatusError
+	if !errors.As(err, &status) {
+		return true
+	}
+
+	switch {
+	case status.StatusCode == http.StatusTooManyRequests, status.StatusCode == http.StatusRequestTimeout:
+		return true
+	case status.StatusCode >= 500:
+		return true
+	}
+
+	return false
+}
+
+// backoff doubles the delay per attempt, unless the sink told us how long to wait.
+func backoff(attempts int, err error) time.Duration {
+	var status *StatusError
+	if errors.As(err, &status) && status.RetryAfter > 0 {
+		return status.RetryAfter
+	}
+
+	delay := baseBackoff << (attempts - 1)
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay
+}
+
+func pendingCount() int {
+	count := 0
+	for _, s := range senders {
+		s.mu.Lock()
+		count += len(s.pending)
+		s.mu.Unlock()
+	}
+	return count
+}
+
+// writeSpool saves every pending delivery so a restart can pick them back up.
+// Callers must hold sendersMu (read or write).
+func writeSpool() error {
+	spoolMu.Lock()
+	defer spoolMu.Unlock()
+
+	// Copy under the sender locks, retries update attempts while we marshal
+	all := []delivery{}
+	for _, s := range senders {
+		s.mu.Lock()
+		for _, d := range s.pending {
+			all = append(all, *d)
+		}
+		s.mu.Unlock()
+	}
+
+	data, err := json.Marshal(all)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification queue: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a half-written spool behind
+	tmp := spoolPath + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(spoolPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+
+	return os.Rename(tmp, spoolPath)
+}
+
+func readSpool() ([]*delivery, error) {
+	data, err := os.ReadFile(spoolPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification queue: %w", err)
+	}
+
+	var spooled []*delivery
+	if err := json.Unmarshal(data, &spooled); err != nil {
+		return nil, fmt.Errorf("failed to decode notification queue %s: %w", spoolPath, err)
+	}
+
+	return spooled, nil
+}
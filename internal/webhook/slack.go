@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+type SlackAttachment struct {
+	Color     string `json:"color"`
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link,omitempty"`
+	Text      string `json:"text"`
+	ThumbURL  string `json:"thumb_url,omitempty"`
+}
+
+type SlackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []SlackAttachment `json:"attachments"`
+}
+
+// SlackNotifier posts messages to a Slack incoming webhook as a colored attachment.
+type SlackNotifier struct {
+	URL string
+}
+
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+func (s *SlackNotifier) Notify(msg Message) error {
+	payload := SlackPayload{
+		// Shown in push notifications and clients that do not render attachments
+		Text: msg.Title,
+		Attachments: []SlackAttachment{{
+			Color:     fmt.Sprintf("#%06x", msg.Color),
+			Title:     msg.Title,
+			TitleLink: msg.URL,
+			// Slack uses single asterisks for bold, everything else in our descriptions renders as-is
+			Text:     strings.ReplaceAll(msg.Description, "**", "*"),
+			ThumbURL: msg.ThumbnailURL,
+		}},
+	}
+
+	if msg.Urgent {
+		payload.Text = "<!channel> " + payload.Text
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
+	}
+
+	return post(s.Name(), s.URL, "application/json", payloadJSON, nil)
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+type TelegramPayload struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// TelegramNotifier sends messages through a Telegram-style bot API (sendMessage).
+// URL only has to be set for self-hosted bot API servers.
+type TelegramNotifier struct {
+	URL    string
+	Token  string
+	ChatID string
+}
+
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+func (t *TelegramNotifier) Notify(msg Message) error {
+	if t.Token == "" || t.ChatID == "" {
+		return fmt.Errorf("telegram webhook needs both a token and a chat_id")
+	}
+
+	base := t.URL
+	if base == "" {
+		base = telegramAPI
+	}
+
+	// Sent as plain text, markdown parse modes reject stray underscores in item names
+	text := fmt.Sprintf("%s\n\n%s", msg.Title, strings.ReplaceAll(msg.Description, "`", ""))
+	if msg.URL != "" {
+		text += "\n\n" + msg.URL
+	}
+
+	payload := TelegramPayload{
+		ChatID: t.ChatID,
+		Text:   text,
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram payload: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(base, "/"), t.Token)
+	return post(t.Name(), url, "application/json", payloadJSON, nil)
+}
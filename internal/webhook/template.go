@@ -0,0 +1,267 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"sniper/internal/parser"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// SnipeDetails is the data handed to the per-event templates.
+type SnipeDetails struct {
+	LimitedID           string
+	ItemName            string
+	ItemType            string // parser.TypeAsset or parser.TypeBundle
+	ItemURL             string
+	ThumbnailURL        string
+	SellerID            int
+	Price               int // Price paid, or the listing price when the purchase did not go through
+	Target              int
+	RAP                 int
+	Savings             int // RAP minus price, negative when we paid over RAP
+	SavingsPercent      float64
+	Balance             int
+	Latency             time.Duration // Purchase request round trip
+	DetectionToPurchase time.Duration // From the scrape that saw the price drop to the purchase answer
+	Message             string
+}
+
+// NewSnipeDetails fills in the derived fields (item link and savings).
+func NewSnipeDetails(details SnipeDetails) SnipeDetails {
+	if details.ItemURL == "" && details.ItemType == parser.TypeBundle {
+		details.ItemURL = BundleURL(details.LimitedID)
+	}
+	if details.ItemURL == "" {
+		details.ItemURL = ItemURL(details.LimitedID)
+	}
+	if details.ItemName == "" {
+		details.ItemName = details.LimitedID
+	}
+	if details.RAP > 0 {
+		details.Savings = details.RAP - details.Price
+		details.SavingsPercent = float64(details.Savings) / float64(details.RAP) * 100
+	}
+	return details
+}
+
+// ItemURL links to the catalog page of an item.
+func ItemURL(limitedID string) string {
+	return fmt.Sprintf("https://www.roblox.com/catalog/%s/", limitedID)
+}
+
+// BundleURL links to the catalog page of a bundle.
+func BundleURL(bundleID string) string {
+	return fmt.Sprintf("https://www.roblox.com/bundles/%s/", bundleID)
+}
+
+// StartupDetails is the data handed to the startup template.
+type StartupDetails struct {
+	Username string
+	UserID   int
+	Limiteds int
+	Balance  int
+}
+
+// BudgetDetails is the data handed to the budget_exhausted template.
+type BudgetDetails struct {
+	Balance        int
+	Spendable      int // Balance minus the configured reserve
+	CheapestTarget int
+	Paused         int
+	Workers        int
+}
+
+// ResaleDetails is the data handed to the resale template.
+type ResaleDetails struct {
+	LimitedID   string
+	ItemName    string
+	ItemURL     string
+	UserAssetID int
+	Paid        int
+	Price       int // Listing price, 0 when the resell failed
+	Profit      int // Price minus what was paid, before Roblox fees
+	Rule        string
+	Message     string
+}
+
+// SaleDetails is the data handed to the sale template.
+type SaleDetails struct {
+	LimitedID   string
+	ItemName    string
+	ItemURL     string
+	UserAssetID int
+	Price       int
+	Proceeds    int // Price minus the marketplace fee
+}
+
+// DiscoveryDetails is the data handed to the discovery template.
+type DiscoveryDetails struct {
+	LimitedID string
+	ItemName  string
+	ItemURL   string
+	Creator   string
+	Price     int
+	RAP       int
+	Discount  float64 // Percent below RAP
+	Target    int     // Generated target, 0 when only alerting
+	Watched   bool
+	Until     time.Time
+}
+
+// AlertDetails is the data handed to the alert template.
+type AlertDetails struct {
+	LimitedID    string
+	ItemName     string
+	ItemURL      string
+	Price        int
+	Target       int
+	SellerID     int
+	SerialNumber int    // UGC copies only
+	ApprovalID   string // Set when the purchase waits for an approval
+	ApprovalURL  string // Signed approval page, empty when approval links are off
+	Reason       string
+	Expires      time.Time
+}
+
+var defaultTemplates = map[EventKind]string{
+	EventStartup: "Account: `{{.Username}}` (`{{.UserID}}`)\n" +
+		"Balance: `R$ {{robux .Balance}}`\n" +
+		"Limiteds: `{{.Limiteds}}`",
+	EventBudgetExhausted: "Balance: `R$ {{robux .Balance}}`\n" +
+		"Spendable: `R$ {{robux .Spendable}}`\n" +
+		"Cheapest Target: `R$ {{robux .CheapestTarget}}`\n" +
+		"Paused Workers: `{{.Paused}}/{{.Workers}}`",
+	EventSuccess: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"Price Paid: `R$ {{robux .Price}}`\n" +
+		"Target: `R$ {{robux .Target}}`\n" +
+		"RAP: `R$ {{robux .RAP}}`\n" +
+		"Savings: `R$ {{robux .Savings}} ({{printf \"%.1f\" .SavingsPercent}}%)`\n" +
+		"Balance: `R$ {{robux .Balance}}`\n" +
+		"Seller ID: `{{.SellerID}}`\n" +
+		"Detection To Purchase: `{{.DetectionToPurchase}}`\n" +
+		"Latency: `{{.Latency}}`",
+	EventFailure: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"Listing Price: `R$ {{robux .Price}}`\n" +
+		"Target: `R$ {{robux .Target}}`\n" +
+		"Latency: `{{.Latency}}`\n" +
+		"Message: `{{.Message}}`",
+	EventError: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"Latency: `{{.Latency}}`\n" +
+		"Message: `{{.Message}}`",
+	EventResale: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"{{if .Price}}Listed For: `R$ {{robux .Price}}`\n{{end}}" +
+		"Paid: `R$ {{robux .Paid}}`\n" +
+		"{{if .Price}}Profit Before Fees: `R$ {{robux .Profit}}`\n{{end}}" +
+		"Rule: `{{.Rule}}`\n" +
+		"User Asset ID: `{{.UserAssetID}}`" +
+		"{{with .Message}}\nMessage: `{{.}}`{{end}}",
+	EventSale: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"Sold For: `R$ {{robux .Price}}`\n" +
+		"Proceeds: `R$ {{robux .Proceeds}}`\n" +
+		"User Asset ID: `{{.UserAssetID}}`",
+	EventDiscovery: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"{{with .Creator}}Creator: `{{.}}`\n{{end}}" +
+		"Lowest Price: `R$ {{robux .Price}}`\n" +
+		"RAP: `R$ {{robux .RAP}}`\n" +
+		"Discount: `{{printf \"%.1f\" .Discount}}%`" +
+		"{{if .Watched}}\nWatching Until: `{{.Until.Format \"Jan 2 15:04\"}}` for `R$ {{robux .Target}}`{{end}}",
+	EventAlert: "**{{.ItemName}}** (`{{.LimitedID}}`)\n" +
+		"Listing Price: `R$ {{robux .Price}}`\n" +
+		"Target: `R$ {{robux .Target}}`\n" +
+		"Seller ID: `{{.SellerID}}`" +
+		"{{with .SerialNumber}}\nSerial: `#{{.}}`{{end}}" +
+		"{{with .ApprovalID}}\nApproval ID: `{{.}}` ({{$.Reason}})\n" +
+		"Approve before `{{$.Expires.Format \"15:04:05\"}}`: " +
+		"{{if $.ApprovalURL}}[approve or deny]({{$.ApprovalURL}}){{else}}`POST /api/approvals/{{.}}/approve`{{end}}{{end}}",
+	EventDigest: "Period: `{{.Start.Format \"Jan 2 15:04\"}}` to `{{.End.Format \"Jan 2 15:04\"}}`\n" +
+		"Polls: `{{.Polls}}`\n" +
+		"Errors: `{{.ErrorCount}}` (`{{printf \"%.2f\" .ErrorRate}}` per 100 polls)\n" +
+		"{{range $type, $count := .Errors}}- {{$type}}: `{{$count}}`\n{{end}}" +
+		"Purchases: `{{len .Purchases}}`\n" +
+		"Total Spent: `R$ {{robux .TotalSpent}}`\n" +
+		"Value Captured: `R$ {{robux .ValueCaptured}}`\n" +
+		"{{with .Sales}}Sales: `{{len .}}` (`R$ {{robux $.TotalProceeds}}` after fees)\n{{end}}" +
+		"{{if .BalanceKnown}}Balance: `R$ {{robux .Balance}}`\n{{end}}" +
+		"{{with .TopNearMisses}}**Near Misses**\n{{range .}}- `{{.LimitedID}}`: {{.Count}}x, closest `R$ {{robux .Closest}}` for `R$ {{robux .Target}}`\n{{end}}{{end}}",
+}
+
+var templateFuncs = template.FuncMap{
+	// robux formats a number with thousands separators
+	"robux": func(amount int) string {
+		digits := strconv.Itoa(amount)
+		negative := amount < 0
+		if negative {
+			digits = digits[1:]
+		}
+
+		var out []byte
+		for i := range digits {
+			if i > 0 && (len(digits)-i)%3 == 0 {
+				out = append(out, ',')
+			}
+			out = append(out, digits[i])
+		}
+
+		if negative {
+			return "-" + string(out)
+		}
+		return string(out)
+	},
+}
+
+var (
+	templates   = map[EventKind]*template.Template{}
+	templatesMu sync.RWMutex
+)
+
+// loadTemplates parses the built-in templates and any overrides from the config.
+func loadTemplates(overrides map[string]string) error {
+	sources := map[EventKind]string{}
+	for event, source := range defaultTemplates {
+		sources[event] = source
+	}
+
+	for name, source := range overrides {
+		event := EventKind(name)
+		if _, err := parseEvents([]string{name}); err != nil {
+			return fmt.Errorf("templates.%s: %w", name, err)
+		}
+		sources[event] = source
+	}
+
+	parsed := make(map[EventKind]*template.Template, len(sources))
+	for event, source := range sources {
+		tmpl, err := template.New(string(event)).Funcs(templateFuncs).Parse(source)
+		if err != nil {
+			return fmt.Errorf("templates.%s: %w", event, err)
+		}
+		parsed[event] = tmpl
+	}
+
+	templatesMu.Lock()
+	templates = parsed
+	templatesMu.Unlock()
+
+	return nil
+}
+
+// Render formats data with the template of the given event.
+func Render(event EventKind, data interface{}) (string, error) {
+	templatesMu.RLock()
+	tmpl, ok := templates[event]
+	templatesMu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("no template for event %q", event)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", event, err)
+	}
+
+	return buf.String(), nil
+}
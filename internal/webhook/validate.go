@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"fmt"
+	"sniper/internal/config"
+	"strings"
+	"text/template"
+)
+
+func init() {
+	config.AddValidator(validate)
+}
+
+// validate checks the webhook sinks and templates of the config.
+func validate(cfg *config.ConfigStruct) []config.FieldError {
+	var problems []config.FieldError
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, config.FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, sink := range cfg.Webhooks {
+		path := fmt.Sprintf("webhooks[%d]", i)
+
+		if _, err := NewNotifier(sink); err != nil {
+			add(path+".type", "%v", err)
+		}
+
+		switch strings.ToLower(sink.Type) {
+		case "telegram":
+			if sink.Token == "" {
+				add(path+".token", "is required for telegram")
+			}
+			if sink.ChatID == "" {
+				add(path+".chat_id", "is required for telegram")
+			}
+		default:
+			if sink.URL == "" {
+				add(path+".url", "is empty")
+			}
+		}
+
+		if _, err := parseEvents(sink.Events); err != nil {
+			add(path+".events", "%v", err)
+		}
+	}
+
+	for name, source := range cfg.Templates {
+		path := "templates." + name
+		if _, err := parseEvents([]string{name}); err != nil {
+			add(path, "%v", err)
+			continue
+		}
+		if _, err := template.New(name).Funcs(templateFuncs).Parse(source); err != nil {
+			add(path, "%v", err)
+		}
+	}
+
+	return problems
+}
@@ -2,69 +2,90 @@ package webhook
 
 import (
 	"bytes"
-	"io/ioutil"
+	"fmt"
+	"io"
 	"net/http"
+	"sniper/internal/redact"
+	"strconv"
 	"time"
 
-	"github.com/charmbracelet/log"
 	"github.com/goccy/go-json"
 )
 
-type Embed struct {
-	Color       int            `json:"color"`
-	Title       string         `json:"title"`
-	Description string         `json:"description"`
-	Thumbnail   EmbedThumbnail `json:"thumbnail"`
+// Shared client for every sink, a slow webhook endpoint should never hang a worker.
+var Client = &http.Client{
+	Timeout: 3 * time.Second,
 }
 
-type EmbedThumbnail struct {
-	URL string `json:"url"`
+// StatusError is returned when a sink answers with a non-2xx status code.
+type StatusError struct {
+	Sink       string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // Zero unless the sink asked us to back off
 }
 
-type WebhookPayload struct {
-	Embeds []Embed `json:"embeds"`
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s webhook responded with status %d: %s", e.Sink, e.StatusCode, e.Body)
 }
 
-func SendWebhook(webhookURL string, embed Embed) {
-	if webhookURL == "" {
-		log.Error("Webhook URL is empty")
-		return
+// post sends a single request to a sink and turns non-2xx answers into a StatusError.
+func post(sink, url, contentType string, body []byte, headers map[string]string) error {
+	if url == "" {
+		return fmt.Errorf("%s webhook URL is empty", sink)
 	}
 
-	payload := WebhookPayload{
-		Embeds: []Embed{embed},
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s webhook request: %w", sink, err)
 	}
 
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		log.Error("Failed to marshal webhook payload:", err)
-		return
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
 
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(payloadJSON))
+	resp, err := Client.Do(req)
 	if err != nil {
-		log.Error("Failed to create new webhook request:", "Request Error", err)
-		return
+		// net/http errors quote the URL, which holds the webhook secret
+		return fmt.Errorf("failed to send %s webhook: %w", sink, redact.Error(err))
 	}
+	defer resp.Body.Close()
 
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{
-		Timeout: 3 * time.Second, // Increased timeout
+	// Check the status code
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &StatusError{
+			Sink:       sink,
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp, respBody),
+		}
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Error("Failed to send webhook:", "Client Error", err)
-		return
+	return nil
+}
+
+// parseRetryAfter reads the Retry-After header, either in seconds or as an HTTP date.
+// Discord also puts a fractional retry_after (seconds) in the body of a 429.
+func parseRetryAfter(resp *http.Response, body []byte) time.Duration {
+	if header := resp.Header.Get("Retry-After"); header != "" {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if date, err := http.ParseTime(header); err == nil {
+			return time.Until(date)
+		}
 	}
-	defer resp.Body.Close()
 
-	// Check the status code
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := ioutil.ReadAll(resp.Body)
-		log.Error("Non-OK webhook response:", resp.Status, string(body))
-		return
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var rateLimit struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		if json.Unmarshal(body, &rateLimit) == nil && rateLimit.RetryAfter > 0 {
+			return time.Duration(rateLimit.RetryAfter * float64(time.Second))
+		}
 	}
 
-	log.Info("Webhook sent successfully with status:", "Status", resp.Status)
+	return 0
 }
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"fmt"
+	"sniper/internal/approval"
+	"sniper/internal/config"
+	"sniper/internal/events"
+	"sniper/internal/parser"
+	"sniper/internal/scraper"
+	"sync"
+	"time"
+)
+
+// alertMark is the last listing an item alerted on.
+type alertMark struct {
+	listing string
+	at      time.Time
+}
+
+var (
+	alerted   = map[string]alertMark{} // Limited ID -> last alert
+	alertedMu sync.Mutex
+)
+
+// listingOf is the bus view of a scraped listing.
+func listingOf(info scraper.ScrapedDetails) events.Listing {
+	return events.Listing{
+		ProductID:             info.ProductID,
+		Price:                 info.Price,
+		SellerID:              info.SellerID,
+		UserAssetID:           info.UserAssetID,
+		CollectibleItemID:     info.CollectibleItemID,
+		CollectibleProductID:  info.CollectibleProductID,
+		CollectibleInstanceID: info.CollectibleInstanceID,
+		SerialNumber:          info.SerialNumber,
+		SellerType:            info.SellerType,
+	}
+}
+
+// listingKey identifies a listing, another copy on sale or a new price gives another key.
+func listingKey(listing events.Listing) string {
+	return fmt.Sprintf("%d/%d/%s/%d/%d", listing.ProductID, listing.UserAssetID, listing.CollectibleInstanceID, listing.SellerID, listing.Price)
+}
+
+// shouldAlert reports a new listing right away and the same listing again once the cooldown is over.
+func shouldAlert(limited_id string, listing events.Listing, cooldown time.Duration) bool {
+	alertedMu.Lock()
+	defer alertedMu.Unlock()
+
+	key := listingKey(listing)
+	last, ok := alerted[limited_id]
+	if ok && last.listing == key && time.Since(last.at) < cooldown {
+		return false
+	}
+
+	alerted[limited_id] = alertMark{listing: key, at: time.Now()}
+	return true
+}
+
+// needsHold reports whether a hit goes through hold instead of being bought right away.
+func needsHold(limited parser.LimitedInfo, listing events.Listing) bool {
+	return limited.Action != parser.ActionBuy || approval.Required(listing.Price)
+}
+
+// hold handles a hit that is not bought right away. Alert entries only notify, confirm entries
+// and buy entries at or above approval.min_price notify and wait for an approval. It reports
+// whether the listing was approved and is still on sale, refetch polls the item again to check.
+func hold(
+	config *config.ConfigStruct,
+	attempt string,
+	limited parser.LimitedInfo,
+	item_name string,
+	listing events.Listing,
+	refetch func() (events.Listing, error),
+) bool {
+	confirm, reason := limited.Action == parser.ActionConfirm, "action is alert-then-confirm"
+	if limited.Action == parser.ActionBuy {
+		confirm, reason = true, "price is at or above approval.min_price"
+	}
+
+	// One held listing per item, later hits wait for its decision. Checked before the cooldown so
+	// a hit while another listing is held is alerted once that one is decided.
+	if confirm && approval.PendingFor(limited.Id) {
+		return false
+	}
+
+	if !shouldAlert(limited.Id, listing, time.Duration(config.Alerts.Cooldown)*time.Minute) {
+		return false
+	}
+
+	alert := events.PriceAlert{
+		Header:   events.Stamp(attempt, limited.Id),
+		Listing:  listing,
+		ItemName: item_name,
+		ItemType: limited.Type,
+		Target:   limited.Price,
+	}
+	if !confirm {
+		events.Publish(alert)
+		return false
+	}
+
+	// Another worker may have opened a request for the item since the check above
+	request, opened := approval.OpenIfAbsent(limited.Id, item_name, listing, limited.Price, reason)
+	if !opened {
+		return false
+	}
+	alert.ApprovalID = request.ID
+	alert.Reason = request.Reason
+	alert.Expires = request.Expires
+	events.Publish(alert)
+
+	decision := request.Wait()
+	if decision == approval.DecisionApproved {
+		// Only the listing that was approved may be bought
+		current, err := refetch()
+		if err != nil || listingKey(current) != listingKey(listing) {
+			decision = approval.DecisionStale
+		}
+	}
+
+	events.Publish(events.ApprovalDecided{
+		Header:     events.Stamp(attempt, limited.Id),
+		Listing:    listing,
+		ApprovalID: request.ID,
+		ItemName:   item_name,
+		Target:     limited.Price,
+		Decision:   decision,
+	})
+
+	return decision == approval.DecisionApproved
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"fmt"
+	"sniper/internal/balance"
+	"sniper/internal/events"
+	"sniper/internal/parser"
+	"sniper/internal/webhook"
+	"sync"
+)
+
+var (
+	Paused    = sync.Map{} // Limited ID -> struct{}, workers waiting for funds
+	exhausted bool
+	budgetMu  sync.Mutex
+)
+
+// checkFunds pauses the worker while its target exceeds the spendable balance and resumes it once funds arrive.
+func checkFunds(limited parser.LimitedInfo) bool {
+	if balance.CanAfford(limited.Price) {
+		if _, was_paused := Paused.LoadAndDelete(limited.Id); was_paused {
+			events.Publish(events.WorkerStateChanged{
+				Header: events.Stamp("", limited.Id),
+				State:  events.StateActive,
+				Target: limited.Price,
+				Reason: fmt.Sprintf("funds are available again, spendable R$ %d", balance.Spendable()),
+			})
+		}
+		return true
+	}
+
+	if _, was_paused := Paused.LoadOrStore(limited.Id, struct{}{}); !was_paused {
+		events.Publish(events.WorkerStateChanged{
+			Header: events.Stamp("", limited.Id),
+			State:  events.StatePaused,
+			Target: limited.Price,
+			Reason: fmt.Sprintf("target exceeds the spendable balance of R$ %d", balance.Spendable()),
+		})
+	}
+	return false
+}
+
+// watchBudget notifies once when no target can be afforded anymore, and once more when funds come back.
+func watchBudget() {
+	balance.OnChange(func(robux int) {
+		limiteds := Watched()
+		if len(limiteds) == 0 {
+			return
+		}
+
+		details := webhook.BudgetDetails{
+			Balance:        robux,
+			Spendable:      balance.Spendable(),
+			CheapestTarget: limiteds[0].Price,
+			Workers:        len(limiteds),
+		}
+		for _, limited := range limiteds {
+			if limited.Price < details.CheapestTarget {
+				details.CheapestTarget = limited.Price
+			}
+			if !balance.CanAfford(limited.Price) {
+				details.Paused++
+			}
+		}
+
+		budgetMu.Lock()
+		defer budgetMu.Unlock()
+
+		affordable := details.Paused < details.Workers
+		if affordable == !exhausted {
+			return
+		}
+		exhausted = !affordable
+
+		title := "Budget Exhausted"
+		color := 0xe03b3b
+		if affordable {
+			title = "Budget Restored"
+			color = 0x3ba55d
+			logger.Info("Funds cover a target again, resuming workers.", "Spendable", details.Spendable)
+		} else {
+			logger.Warn("No target fits in the spendable balance, all workers paused.", "Spendable", details.Spendable, "Cheapest Target", details.CheapestTarget)
+		}
+
+		description, err := webhook.Render(webhook.EventBudgetExhausted, details)
+		if err != nil {
+			logger.Error("Could not render webhook template", "Event", webhook.EventBudgetExhausted, "Error", err)
+			return
+		}
+
+		webhook.Notify(webhook.Message{
+			Event:       webhook.EventBudgetExhausted,
+			Title:       title,
+			Description: description,
+			Color:       color,
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"fmt"
+	"sniper/internal/config"
+	"sniper/internal/events"
+	"sniper/internal/logging"
+	"sniper/internal/parser"
+	"sniper/internal/rules"
+	"sniper/internal/scraper"
+	"sniper/internal/session"
+	"sync"
+	"time"
+)
+
+// dropWorker watches an off-sale item and buys it at list price as soon as its creator puts it on sale.
+// Without max_owned it stops after the first copy, with it it keeps buying up to the cap.
+func dropWorker(quit <-chan struct{}, config *config.ConfigStruct, limited parser.LimitedInfo) {
+	var item_name string
+	if name, err := scraper.FetchItemName(session.Cookie(), limited); err != nil {
+		logger.Warn("Could not fetch item name", "Limited ID", limited.Id, "Error", err)
+	} else {
+		item_name = name
+	}
+
+	stop := make(chan struct{})
+	var stop_once sync.Once
+	halt := func(reason string) {
+		stop_once.Do(func() {
+			stopped(limited, reason)
+			close(stop)
+		})
+	}
+
+	events.Publish(events.WorkerStateChanged{
+		Header:   events.Stamp("", limited.Id),
+		ItemName: item_name,
+		Source:   limited.Source,
+		State:    events.StateActive,
+		Target:   limited.Price,
+		Reason:   fmt.Sprintf("waiting for the release at %s", limited.Release.Local().Format(time.RFC1123)),
+	})
+
+	warned_price := false
+	for {
+		wait := dropInterval(config.Drop, limited.Release, time.Now())
+
+		if session.Active() && !Held(limited.Id) && checkFunds(limited) && checkOwnership(limited, halt) {
+			attempt := logging.NewAttemptID()
+			start := time.Now()
+
+			info, on_sale, err := scraper.FetchDrop(session.Cookie(), limited)
+			switch {
+			case err != nil:
+				events.Publish(events.ScrapeFailed{Header: events.Stamp(attempt, limited.Id), Err: err})
+			case on_sale:
+				listing := listingOf(info)
+				observed := events.Stamp(attempt, limited.Id)
+				events.Publish(events.PriceObserved{
+					Header:  observed,
+					Listing: listing,
+					Target:  limited.Price,
+					Latency: time.Since(start),
+				})
+
+				if !rules.ShouldBuy(limited, listing) {
+					if !warned_price {
+						warned_price = true
+						logging.Attempt("worker", attempt).Warn("Drop is on sale above the target, not buying.", "Limited ID", limited.Id, "Price", listing.Price, "Target", limited.Price)
+					}
+					break
+				}
+
+				if needsHold(limited, listing) {
+					refetch := func() (events.Listing, error) {
+						current, still_on_sale, err := scraper.FetchDrop(session.Cookie(), limited)
+						if err == nil && !still_on_sale {
+							err = fmt.Errorf("no longer on sale")
+						}
+						return listingOf(current), err
+					}
+					if !hold(config, attempt, limited, item_name, listing, refetch) {
+						break
+					}
+				}
+
+				events.Publish(events.TargetHit{Header: events.Stamp(attempt, limited.Id), Listing: listing, Target: limited.Price})
+				if buy(attempt, limited, item_name, listing, observed.Time) {
+					if limited.MaxOwned <= 0 {
+						halt("bought the drop")
+					}
+					// Go for the next copy right away while the drop lasts
+					wait = 0
+				}
+			}
+		}
+
+		select {
+		case <-quit:
+			return
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// dropInterval is the wait before the next sale status check: idle far from the release, shrinking
+// linearly to fast over the ramp window, and fast for the hot period after the release.
+// It never sleeps past the start of the ramp window or the release itself.
+func dropInterval(cfg config.DropConfig, release, now time.Time) time.Duration {
+	idle := time.Duration(cfg.IdleInterval) * time.Second
+	fast := time.Duration(cfg.FastInterval) * time.Millisecond
+	window := time.Duration(cfg.RampWindow) * time.Second
+	hot := time.Duration(cfg.HotPeriod) * time.Second
+
+	until := release.Sub(now)
+	switch {
+	case until <= 0 && -until <= hot:
+		return fast
+	case until <= 0:
+		// Released a while ago without going on sale, back to the idle pace
+		return idle
+	case until > window:
+		return max(fast, min(idle, until-window))
+	}
+
+	ramped := fast + time.Duration(float64(idle-fast)*float64(until)/float64(window))
+	return max(fast, min(ramped, until))
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"sniper/internal/config"
+	"testing"
+	"time"
+)
+
+func TestDropInterval(t *testing.T) {
+	cfg := config.DropConfig{IdleInterval: 60, RampWindow: 600, FastInterval: 500, HotPeriod: 900}
+	release := time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		until time.Duration // Time left before the release, negative once released
+		want  time.Duration
+	}{
+		{"far from the release", 2 * time.Hour, time.Minute},
+		{"wakes up for the ramp window", 10*time.Minute + 20*time.Second, 20 * time.Second},
+		{"start of the ramp window", 10 * time.Minute, time.Minute},
+		{"middle of the ramp window", 5 * time.Minute, 30250 * time.Millisecond},
+		{"close to the release", time.Minute, 6450 * time.Millisecond},
+		{"fast right before the release", 100 * time.Millisecond, 500 * time.Millisecond},
+		{"at the release", 0, 500 * time.Millisecond},
+		{"hot period", -10 * time.Minute, 500 * time.Millisecond},
+		{"end of the hot period", -15 * time.Minute, 500 * time.Millisecond},
+		{"released long ago", -time.Hour, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dropInterval(cfg, release, release.Add(-tt.until)); got != tt.want {
+				t.Errorf("%v before the release: got %v, want %v", tt.until, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropIntervalWithoutRamp(t *testing.T) {
+	cfg := config.DropConfig{IdleInterval: 60, RampWindow: 0, FastInterval: 500, HotPeriod: 0}
+	release := time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		until time.Duration
+		want  time.Duration
+	}{
+		{time.Hour, time.Minute},
+		{30 * time.Second, 30 * time.Second},
+		{0, 500 * time.Millisecond},
+		{-time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := dropInterval(cfg, release, release.Add(-tt.until)); got != tt.want {
+			t.Errorf("%v before the release: got %v, want %v", tt.until, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package worker
+
+import (
+	"fmt"
+	"sniper/internal/config"
+	"sniper/internal/events"
+	"sniper/internal/parser"
+	"sync"
+)
+
+var (
+	running   = map[string]chan struct{}{} // Limited ID -> quit channel of its worker
+	watched   = map[string]parser.LimitedInfo{}
+	order     []string            // Limited IDs in the order they were added
+	held      = map[string]bool{} // Limited IDs paused by hand, unlike Paused which waits for funds
+	runConfig *config.ConfigStruct
+	runMu     sync.Mutex
+)
+
+// Add starts a worker for an item that is not watched yet.
+func Add(limited parser.LimitedInfo) error {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	if runConfig == nil {
+		return fmt.Errorf("workers are not running")
+	}
+	if _, ok := running[limited.Id]; ok {
+		return fmt.Errorf("limited %s is already watched", limited.Id)
+	}
+
+	addLocked(limited)
+	return nil
+}
+
+// Replace restarts the worker of a watched item with new settings, e.g. another target.
+// A paused item stays paused.
+func Replace(limited parser.LimitedInfo) error {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	quit, ok := running[limited.Id]
+	if !ok {
+		return fmt.Errorf("limited %s is not watched", limited.Id)
+	}
+
+	was_held := held[limited.Id]
+	position := 0
+	for i, id := range order {
+		if id == limited.Id {
+			position = i
+		}
+	}
+	forgetLocked(limited.Id, quit)
+	close(quit)
+
+	addLocked(limited)
+	if was_held {
+		held[limited.Id] = true
+	}
+
+	// Keep the item where it was in the watchlist
+	copy(order[position+1:], order[position:len(order)-1])
+	order[position] = limited.Id
+	return nil
+}
+
+// addLocked starts the worker of an item, callers hold runMu.
+func addLocked(limited parser.LimitedInfo) {
+	quit := make(chan struct{})
+	running[limited.Id] = quit
+	watched[limited.Id] = limited
+	order = append(order, limited.Id)
+
+	go func(config *config.ConfigStruct) {
+		if limited.IsDrop() {
+			dropWorker(quit, config, limited)
+		} else {
+			worker(quit, config, limited)
+		}
+		forget(limited.Id, quit)
+	}(runConfig)
+}
+
+// Remove stops the worker of an item, it reports false when the item was not watched.
+func Remove(id string) bool {
+	runMu.Lock()
+	quit, ok := running[id]
+	limited := watched[id]
+	if ok {
+		forgetLocked(id, quit)
+	}
+	runMu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	close(quit)
+	stopped(limited, "removed from the watchlist")
+	return true
+}
+
+// Pause stops an item from being polled and bought until Resume, it reports false when the
+// item is not watched or already paused.
+func Pause(id string) bool {
+	runMu.Lock()
+	limited, ok := watched[id]
+	ok = ok && !held[id]
+	if ok {
+		held[id] = true
+	}
+	runMu.Unlock()
+
+	if ok {
+		events.Publish(events.WorkerStateChanged{
+			Header: events.Stamp("", id),
+			State:  events.StatePaused,
+			Target: limited.Price,
+			Reason: "paused by hand",
+		})
+	}
+	return ok
+}
+
+// Resume undoes Pause.
+func Resume(id string) bool {
+	runMu.Lock()
+	limited, ok := watched[id]
+	ok = ok && held[id]
+	delete(held, id)
+	runMu.Unlock()
+
+	if ok {
+		events.Publish(events.WorkerStateChanged{
+			Header: events.Stamp("", id),
+			State:  events.StateActive,
+			Target: limited.Price,
+			Reason: "resumed by hand",
+		})
+	}
+	return ok
+}
+
+// Held reports whether an item was paused by hand.
+func Held(id string) bool {
+	runMu.Lock()
+	defer runMu.Unlock()
+	return held[id]
+}
+
+// Watched returns the items with a running worker, in the order they were added.
+func Watched() []parser.LimitedInfo {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	limiteds := make([]parser.LimitedInfo, 0, len(order))
+	for _, id := range order {
+		limiteds = append(limiteds, watched[id])
+	}
+	return limiteds
+}
+
+// forget drops a finished worker, unless the item was added again in the meantime.
+func forget(id string, quit chan struct{}) {
+	runMu.Lock()
+	defer runMu.Unlock()
+	forgetLocked(id, quit)
+}
+
+func forgetLocked(id string, quit chan struct{}) {
+	if running[id] != quit {
+		return
+	}
+
+	delete(running, id)
+	delete(watched, id)
+	delete(held, id)
+	for i, watchedID := range order {
+		if watchedID == id {
+			order = append(order[:i], order[i+1:]...)
+			break
+		}
+	}
+}
@@ -3,30 +3,29 @@ package worker
 import (
 	"fmt"
 	"net/http"
+	"sniper/internal/balance"
 	"sniper/internal/config"
 	"sniper/internal/csrf"
+	"sniper/internal/events"
+	"sniper/internal/inventory"
+	"sniper/internal/logging"
+	"sniper/internal/metrics"
 	"sniper/internal/parser"
 	"sniper/internal/purchase"
+	"sniper/internal/rules"
 	"sniper/internal/scraper"
-	"sniper/internal/webhook"
+	"sniper/internal/session"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/charmbracelet/log"
 )
 
-type LastCheck struct {
-	TimeTaken time.Duration
-}
+var logger = logging.For("worker")
 
 var (
-	Client          = &http.Client{} // Initialize the HTTP client
-	PurchasedItems  = sync.Map{}
-	FailedItems     = sync.Map{}
-	IterationChecks = sync.Map{}
-	InQueue         = sync.Map{}
-	Mutex           sync.Mutex
-	wg              sync.WaitGroup // WaitGroup for managing webhook completion
+	Client  = &http.Client{} // Initialize the HTTP client
+	InQueue = sync.Map{}
+	Mutex   sync.Mutex
 )
 
 func worker(
@@ -39,156 +38,151 @@ func worker(
 		case <-quit:
 			return
 		default:
-			log.Debug("Fetching Limited Information For Record")
-			first_info, limited_error := scraper.ScrapeItemDetails(config.Cookie, limited.Id)
+			logger.Debug("Fetching Limited Information For Record", "Limited ID", limited.Id)
+			first_info, limited_error := scraper.FetchListing(session.Cookie(), limited)
 			if limited_error != nil {
-				log.Error(limited_error)
+				stopped(limited, limited_error.Error())
 				return
 			}
 
-			if first_info.ProductID == 0 || first_info.Price < 0 {
-				log.Warn("Could not fetch data for worker to start, skipping.", "Limited ID", limited.Id)
+			if (first_info.ProductID == 0 && first_info.CollectibleItemID == "") || first_info.Price < 0 {
+				stopped(limited, "could not fetch data for worker to start")
 				return
 			}
 
-			log.Info("Worker Activated", "Id", limited.Id, "Price", first_info.Price)
+			// The name only decorates notifications, a failure here should not stop the worker
+			var item_name string
+			if name, err := scraper.FetchItemName(session.Cookie(), limited); err != nil {
+				logger.Warn("Could not fetch item name", "Limited ID", limited.Id, "Error", err)
+			} else {
+				item_name = name
+			}
+
+			// Stops the worker for good, e.g. once the item reached its max_owned limit
+			stop := make(chan struct{})
+			var stop_once sync.Once
+			halt := func(reason string) {
+				stop_once.Do(func() {
+					stopped(limited, reason)
+					close(stop)
+				})
+			}
 
-			iteration_count := 1
+			if !checkOwnership(limited, halt) {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+
+			events.Publish(events.WorkerStateChanged{
+				Header:   events.Stamp("", limited.Id),
+				ItemName: item_name,
+				Source:   limited.Source,
+				State:    events.StateActive,
+				Target:   limited.Price,
+				Reason:   fmt.Sprintf("lowest price R$ %d", first_info.Price),
+			})
+
+			// Every tick runs in its own goroutine
+			var iteration_count atomic.Int64
+			iteration_count.Store(1)
 			for {
+				select {
+				case <-stop:
+					return
+				case <-quit:
+					return
+				default:
+				}
+
 				go func() {
-					if config.Verbose {
-						if check, ok := IterationChecks.Load(limited.Id); ok {
-							log.Info("[Interval Pass]:", "Get Info Latency:", check.(LastCheck).TimeTaken, "Iteration Count", iteration_count)
-						}
-						if _, ok := FailedItems.Load(limited.Id); ok {
-							log.Info("Item Has Failed Before. Waiting One Second", "Limited ID", limited.Id)
-							time.Sleep(time.Second * 1)
-						}
+					// Every log line, event and notification of this attempt carries the same id
+					attempt := logging.NewAttemptID()
+					wlog := logging.Attempt("worker", attempt).With("Limited ID", limited.Id)
+
+					// Verbose lines go out at debug, so verbose: true and the dashboard's v key both show them
+					if check, ok := metrics.IterationChecks.Load(limited.Id); ok {
+						wlog.Debug("[Interval Pass]:", "Get Info Latency:", check.(metrics.LastCheck).TimeTaken, "Iteration Count", iteration_count.Load())
+					}
+					if config.Verbose && metrics.HasFailed(limited.Id) {
+						wlog.Debug("Item Has Failed Before. Waiting One Second")
+						time.Sleep(time.Second * 1)
+					}
+
+					// Nothing can be bought until a new cookie arrives
+					if !session.Active() || Held(limited.Id) {
+						return
+					}
+
+					if !checkFunds(limited) {
+						return
+					}
+
+					if !checkOwnership(limited, halt) {
+						return
 					}
 
 					start := time.Now()
 
 					if in_queue, _ := InQueue.LoadOrStore(limited.Id, false); in_queue.(bool) {
-						if config.Verbose {
-							log.Warn("Limited is in the process of being sniped, Continuing Loop.")
-						}
+						wlog.Debug("Limited is in the process of being sniped, Continuing Loop.")
 						time.Sleep(time.Millisecond * time.Duration(config.Rate))
 						return
 					}
 
-					info, err := scraper.ScrapeItemDetails(config.Cookie, limited.Id)
+					info, err := scraper.FetchListing(session.Cookie(), limited)
 					if err != nil {
-						log.Error(err)
+						events.Publish(events.ScrapeFailed{Header: events.Stamp(attempt, limited.Id), Err: err})
 						time.Sleep(time.Millisecond * time.Duration(config.Rate))
 						return
 					}
 
-					if info.Price <= limited.Price || (info.Price != 0 || info.Price != -1) {
-						InQueue.Store(limited.Id, true)
-						if config.Verbose {
-							log.Warn("Lower Than Expected Price Detected.", "Limited ID", limited.Id)
-						}
-
-						purchase_response, purchase_error := purchase.MakePurchase(csrf.Token, config.Cookie, info.ProductID, info.Price, first_info.SellerID, first_info.UserAssetID)
+					listing := listingOf(info)
+					observed := events.Stamp(attempt, limited.Id)
+					events.Publish(events.PriceObserved{
+						Header:  observed,
+						Listing: listing,
+						Target:  limited.Price,
+						Latency: time.Since(start),
+					})
 
-						var thumbnail_url string
-						thumbnail, err := scraper.GetThumbnail(limited.Id)
-						if err != nil {
-							fmt.Println("Error:", err)
-						} else {
-							fmt.Println(thumbnail)
-							thumbnail_url = thumbnail.ImageUrl
-						}
+					// Our own resale listing can be the cheapest one, it is never bought back
+					if listing.SellerID == session.User().Id {
+						return
+					}
 
-						if purchase_error == nil {
-							InQueue.Store(limited.Id, false)
-							if purchase_response.Purchased {
-								PurchasedItems.Store(limited.Id, struct{}{})
-
-								embed := webhook.Embed{
-									Title: "Limited Snipe Success",
-									Description: fmt.Sprintf("Item Purchase: `%s`\nSeller ID: `%d`\nLatency: `%v`",
-										limited.Id,
-										info.SellerID,
-										purchase_response.Latency,
-									),
-									Color: 0xF58A42,
-									Thumbnail: webhook.EmbedThumbnail{
-										URL: thumbnail_url,
-									},
-								}
-
-								wg.Add(1) // Increment the wait group counter
-								go func() {
-									defer wg.Done() // Decrement the wait group counter
-									webhook.SendWebhook(config.WebhookURL, embed)
-								}()
-
-								// Wait until the webhook sending is complete
-								wg.Wait()
-
-								log.Warn("Sniped Successfully Executed", "Message", purchase_response.ErrorMsg)
-								return
-							} else {
-								FailedItems.Store(limited.Id, struct{}{})
-								embed := webhook.Embed{
-									Title: "Purchase Failure",
-									Description: fmt.Sprintf("Limited ID: `%s`\nLatency: `%v`\nMessage: `%s`",
-										limited.Id,
-										purchase_response.Latency,
-										purchase_response.ErrorMsg,
-									),
-									Color: 0x8115ed,
-									Thumbnail: webhook.EmbedThumbnail{
-										URL: thumbnail_url,
-									},
-								}
-
-								wg.Add(1) // Increment the wait group counter
-								go func() {
-									defer wg.Done() // Decrement the wait group counter
-									webhook.SendWebhook(config.WebhookURL, embed)
-								}()
-
-								// Wait until the webhook sending is complete
-								wg.Wait()
-
-								log.Warn("Purchase Failure", "Message", purchase_response.ErrorMsg)
+					if rules.ShouldBuy(limited, listing) {
+						if needsHold(limited, listing) {
+							refetch := func() (events.Listing, error) {
+								current, err := scraper.FetchListing(session.Cookie(), limited)
+								return listingOf(current), err
 							}
-						} else {
-							FailedItems.Store(limited.Id, struct{}{})
-							embed := webhook.Embed{
-								Title: "Error",
-								Description: fmt.Sprintf("Limited Item ID: `%s`\nLatency: `%v`\nMessage: `%v`",
-									limited.Id,
-									purchase_response.Latency,
-									purchase_error,
-								),
-								Color: 0xd11197,
-								Thumbnail: webhook.EmbedThumbnail{
-									URL: thumbnail_url,
-								},
+							if !hold(config, attempt, limited, item_name, listing, refetch) {
+								return
 							}
+						}
 
-							wg.Add(1) // Increment the wait group counter
-							go func() {
-								defer wg.Done() // Decrement the wait group counter
-								webhook.SendWebhook(config.WebhookURL, embed)
-							}()
-
-							// Wait until the webhook sending is complete
-							wg.Wait()
+						// Ticks started within one fetch all get here, only the one claiming the slot buys
+						if !InQueue.CompareAndSwap(limited.Id, false, true) {
+							return
 						}
+						defer InQueue.Store(limited.Id, false)
 
-						if config.Verbose {
-							log.Info(fmt.Sprintf("Sniping Limited: Price: %d. Actual: %d", limited.Price, info.Price))
+						events.Publish(events.TargetHit{Header: events.Stamp(attempt, limited.Id), Listing: listing, Target: limited.Price})
+
+						// Another attempt may have bought a copy since this one started, checked while
+						// holding the slot so the count includes it
+						if !checkOwnership(limited, halt) {
+							return
 						}
-					}
+						buy(attempt, limited, item_name, listing, observed.Time)
 
-					iteration_check := time.Since(start)
-					IterationChecks.Store(limited.Id, LastCheck{TimeTaken: iteration_check})
-					iteration_count++
+						wlog.Debug(fmt.Sprintf("Sniping Limited: Price: %d. Actual: %d", limited.Price, info.Price))
+					}
 
+					iteration_count.Add(1)
 				}()
 				time.Sleep(time.Millisecond * time.Duration(config.Rate))
 			}
@@ -196,16 +190,139 @@ func worker(
 	}
 }
 
-func Run(config *config.ConfigStruct, limiteds []parser.LimitedInfo) {
-	quit := make(chan struct{})
-	for i := 0; i < len(limiteds); i++ {
-		go func() {
-			limited := limiteds[i]
-			worker(quit, config, limited)
-		}()
+// buy sends the purchase for the listing and publishes the outcome, it reports whether the copy was bought.
+// observed is when the scrape that found the listing returned. Every side effect (notifications,
+// the ledger, metrics) happens in subscribers.
+func buy(attempt string, limited parser.LimitedInfo, item_name string, listing events.Listing, observed time.Time) bool {
+	events.Publish(events.PurchaseAttempted{Header: events.Stamp(attempt, limited.Id), Listing: listing, Target: limited.Price})
+
+	var purchase_response *purchase.PurchaseResponse
+	var purchase_error error
+	switch limited.Kind {
+	case parser.KindUGC:
+		collectible := purchase.Collectible{
+			ItemID:     listing.CollectibleItemID,
+			ProductID:  listing.CollectibleProductID,
+			InstanceID: listing.CollectibleInstanceID,
+			SellerType: listing.SellerType,
+		}
+		purchase_response, purchase_error = purchase.MakeCollectiblePurchase(csrf.Token, session.Cookie(), session.User().Id, collectible, listing.Price, listing.SellerID)
+	default:
+		purchase_response, purchase_error = purchase.MakePurchase(csrf.Token, session.Cookie(), listing.ProductID, listing.Price, listing.SellerID, listing.UserAssetID)
 	}
+	detection_to_purchase := time.Since(observed)
 
-	for {
-		time.Sleep(time.Hour)
+	if purchase_error != nil {
+		// A rejected purchase is the first sign of an expired cookie
+		session.CheckNow()
+
+		robux, _ := balance.Get()
+		events.Publish(events.PurchaseFailed{
+			Header:   events.Stamp(attempt, limited.Id),
+			Listing:  listing,
+			ItemName: item_name,
+			ItemType: limited.Type,
+			Target:   limited.Price,
+			Message:  purchase_error.Error(),
+			Balance:  robux,
+		})
+		return false
+	}
+
+	if !purchase_response.Purchased {
+		if purchase_response.ShortfallPrice > 0 {
+			balance.Refresh()
+		}
+
+		robux, _ := balance.Get()
+		events.Publish(events.PurchaseFailed{
+			Header:    events.Stamp(attempt, limited.Id),
+			Listing:   listing,
+			ItemName:  item_name,
+			ItemType:  limited.Type,
+			Target:    limited.Price,
+			Rejected:  true,
+			Message:   purchase_response.ErrorMsg,
+			Shortfall: purchase_response.ShortfallPrice,
+			Balance:   robux,
+		})
+		return false
 	}
+
+	if purchase_response.Price > 0 {
+		listing.Price = purchase_response.Price
+	}
+
+	// The classic purchase answer already carries the new balance, the UGC one does not
+	if purchase_response.BalanceAfterSale <= 0 {
+		if robux, known := balance.Get(); known {
+			purchase_response.BalanceAfterSale = robux - listing.Price
+		}
+	}
+
+	// Confirm the new balance with a fresh fetch
+	balance.Set(purchase_response.BalanceAfterSale)
+	balance.Refresh()
+	inventory.Add(limited.Id, listing.UserAssetID)
+
+	events.Publish(events.PurchaseSucceeded{
+		Header:              events.Stamp(attempt, limited.Id),
+		Listing:             listing,
+		ItemName:            item_name,
+		ItemType:            limited.Type,
+		Target:              limited.Price,
+		Balance:             purchase_response.BalanceAfterSale,
+		Latency:             purchase_response.Latency,
+		DetectionToPurchase: detection_to_purchase,
+	})
+
+	return true
+}
+
+// checkOwnership enforces max_owned. Items with a cap are not bought while the inventory is unknown,
+// and the worker is halted once the cap is reached.
+func checkOwnership(limited parser.LimitedInfo, halt func(reason string)) bool {
+	if limited.MaxOwned <= 0 {
+		return true
+	}
+
+	owned, known := inventory.Owned(limited.Id)
+	if !known {
+		logger.Debug("Inventory not loaded yet, holding purchases of a capped item.", "Limited ID", limited.Id)
+		return false
+	}
+
+	if !rules.UnderCap(limited, owned) {
+		halt(fmt.Sprintf("owns %d copies, max_owned is %d", owned, limited.MaxOwned))
+		return false
+	}
+
+	return true
+}
+
+// stopped reports a worker that gave up on its item.
+func stopped(limited parser.LimitedInfo, reason string) {
+	events.Publish(events.WorkerStateChanged{
+		Header: events.Stamp("", limited.Id),
+		State:  events.StateStopped,
+		Reason: reason,
+		Target: limited.Price,
+	})
+}
+
+// Start starts a worker for every item of the limiteds file, more items can be
+// added and removed while running (see Add and Remove).
+func Start(config *config.ConfigStruct, limiteds []parser.LimitedInfo) {
+	runMu.Lock()
+	runConfig = config
+	runMu.Unlock()
+
+	for _, limited := range limiteds {
+		if err := Add(limited); err != nil {
+			logger.Warn("Skipping limited", "Limited ID", limited.Id, "Error", err)
+		}
+	}
+
+	// Registered once the workers are added, a balance already known is checked against them right away
+	watchBudget()
 }
@@ -3,16 +3,37 @@ package main
 import (
 	"fmt"
 	"os"
+	"sniper/internal/approval"
+	"sniper/internal/balance"
 	"sniper/internal/config"
+	"sniper/internal/control"
 	"sniper/internal/csrf"
+	"sniper/internal/dashboard"
+	"sniper/internal/discovery"
+	"sniper/internal/eventlog"
+	"sniper/internal/events"
+	"sniper/internal/inventory"
+	"sniper/internal/ledger"
+	"sniper/internal/listings"
+	"sniper/internal/logging"
+	"sniper/internal/metrics"
+	"sniper/internal/notify"
 	"sniper/internal/parser"
+	"sniper/internal/redact"
+	"sniper/internal/report"
+	"sniper/internal/resell"
 	"sniper/internal/scraper"
+	"sniper/internal/secrets"
+	"sniper/internal/session"
+	"sniper/internal/tape"
+	"sniper/internal/tui"
+	"sniper/internal/webhook"
 	"sniper/internal/worker"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/urfave/cli/v2"
-	"golang.org/x/time/rate"
+	"golang.org/x/term"
 )
 
 func main() {
@@ -22,8 +43,21 @@ func main() {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "file",
-				Usage: "Path to the main file with Limited information, format is <price>,<id>",
+				Usage: "Path to the main file with Limited information, format is <id>,<price>[,key=value...]",
 			},
+			configFlag,
+			&cli.BoolFlag{
+				Name:  "tui",
+				Usage: "Show a full-screen dashboard of the watched items instead of the scrolling log",
+			},
+		},
+		Commands: []*cli.Command{
+			doctorCommand,
+			secretsCommand,
+			configCommand,
+			tapeCommand,
+			backtestCommand,
+			discoveriesCommand,
 		},
 		Action: func(ctx *cli.Context) error {
 			// proxy_path := ctx.String("proxy")
@@ -42,12 +76,36 @@ func main() {
 			csrf.Init(5 * time.Second)
 
 			// Read and Setup Configuration File.
-			cfg, config_error := config.LoadConfig("config.yaml")
+			config_path := ctx.String("config")
+			cfg, config_error := config.LoadConfig(config_path)
 			if config_error != nil {
-				log.Error("Please verify that a config.yaml file exists, exiting.")
+				log.Error("Please verify that the config file exists, exiting.", "Path", config_path, "Error", config_error)
 				return nil
 			} else {
-				log.Info("🔧 Configuration Has Been Loaded")
+				log.Info("🔧 Configuration Has Been Loaded", "Path", config_path)
+			}
+
+			// Credentials can live outside of config.yaml
+			if secrets_error := secrets.Apply(cfg); secrets_error != nil {
+				log.Error("Could not load credentials.", "Error", secrets_error)
+				return nil
+			}
+			redact.Register(config.Secrets(cfg)...)
+
+			if validation_error := config.Validate(cfg); validation_error != nil {
+				log.Error(validation_error)
+				return nil
+			}
+
+			if logging_error := setupLogging(cfg); logging_error != nil {
+				log.Error("Invalid log configuration.", "Error", logging_error)
+				return nil
+			}
+
+			// Setup notification sinks
+			if webhook_error := webhook.Init(cfg); webhook_error != nil {
+				log.Error("Invalid webhook configuration.", "Error", webhook_error)
+				return nil
 			}
 
 			limiteds, lim_error := parser.FromFile(file_path)
@@ -70,36 +128,132 @@ func main() {
 			// Fetch CSRF
 			csrf_error := csrf.UpdateCSRF(cfg.Cookie)
 			if csrf_error != nil {
-				log.Errorf("Something went wrong while fetching a CSRF token. %s", csrf_error)
+				log.Errorf("Something went wrong while fetching a CSRF token, run `sniper doctor` for details. %s", csrf_error)
 			} else {
-				log.Info("🪙 Successfuly Retreived CSRF-Token", "Token", csrf.Token)
+				log.Info("🪙 Successfuly Retreived CSRF-Token")
 			}
 
 			bot_info, bot_err := scraper.FetchAuthenticated(cfg.Cookie)
 			if bot_err != nil {
 				log.Error("Error Occured While Bot Information", bot_err)
 			} else {
-        
-if (bot_info.Id <= 0) {
-
-        log.Error("Authentication Failed, Re-try with a valid Cookie.")
-        return nil
-      }
+				if bot_info.Id <= 0 {
+					log.Error("Authentication Failed, Re-try with a valid Cookie.")
+					return nil
+				}
 
 				log.Info(fmt.Sprintf("Authentiated As %s(%d).", bot_info.Username, bot_info.Id))
 			}
 
-			// Initialize a new rate limiter
-			limiter := rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Rate)
+			// Watch the session for cookie expiry, a new cookie can be dropped into the config while running
+			session.Init(cfg.Cookie, bot_info)
+			session.Start(time.Duration(cfg.Session.CheckInterval) * time.Second)
+			if bot_info.Id <= 0 {
+				session.CheckNow()
+			}
+			go config.Watch(config_path, func(reloaded *config.ConfigStruct) {
+				if err := secrets.Apply(reloaded); err != nil {
+					log.Error("Could not load credentials, keeping the old config.", "Error", err)
+					return
+				}
+				redact.Register(config.Secrets(reloaded)...)
+				if err := config.Validate(reloaded); err != nil {
+					log.Error("Reloaded config is invalid, keeping the old one.", "Error", err)
+					return
+				}
+				if err := setupLogging(reloaded); err != nil {
+					log.Error("Invalid log configuration, keeping the old one.", "Error", err)
+				}
+				if err := webhook.Init(reloaded); err != nil {
+					log.Error("Invalid webhook configuration, keeping the old sinks.", "Error", err)
+				}
+				session.SetCookie(reloaded.Cookie)
+			})
+
+			// Keep track of the balance so workers pause on targets we can't afford
+			balance.Start(time.Duration(cfg.Balance.RefreshInterval)*time.Second, cfg.Balance.Reserve)
+			report.Balance = balance.Get
+
+			// Owned copies back the max_owned rule of the limiteds file
+			inventory.Start(time.Duration(cfg.Inventory.RefreshInterval) * time.Second)
+
+			robux, _ := balance.Get()
+
+			startup_description, render_error := webhook.Render(webhook.EventStartup, webhook.StartupDetails{
+				Username: bot_info.Username,
+				UserID:   bot_info.Id,
+				Limiteds: len(limiteds),
+				Balance:  robux,
+			})
+			if render_error != nil {
+				log.Error("Could not render webhook template", "Error", render_error)
+			}
+
+			webhook.Notify(webhook.Message{
+				Event:       webhook.EventStartup,
+				Title:       "Sniper Started",
+				Description: startup_description,
+				Color:       0x42b3f5,
+			})
+
+			// Event log backs the digest reports
+			if event_error := eventlog.Init(cfg.EventLog); event_error != nil {
+				log.Error("Could not open the event log.", "Error", event_error)
+				return nil
+			}
+
+			// Side effects of the workers run as bus subscribers, none of them can hold up a purchase
+			events.LogEvents()
+			metrics.Start()
+			ledger.Start(cfg.Digest.NearMissPercent)
+			notify.Start()
+			if ctx.Bool("tui") {
+				tui.Start()
+			}
+			dashboard.Start(cfg.Control)
+			if tape_error := tape.Start(cfg.Tape); tape_error != nil {
+				log.Error("Could not start the price tape, listings will not be recorded.", "Error", tape_error)
+			}
+
+			// Sniped copies of items with a resell rule are listed once their hold is over
+			if resell_error := resell.Start(cfg.Resell, limiteds); resell_error != nil {
+				log.Error("Could not start auto-resell", "Error", resell_error)
+			}
+			if listings_error := listings.Start(cfg.Listings, limiteds); listings_error != nil {
+				log.Error("Could not start the listing manager", "Error", listings_error)
+			}
+
+			if cfg.Digest.Interval != "" {
+				go report.Run(cfg.Digest)
+			}
+
+			// Confirm entries and expensive listings hold their purchases for approval
+			approval.Init(cfg.Approval)
+			if cfg.Approval.Prompt && !ctx.Bool("tui") && term.IsTerminal(int(os.Stdin.Fd())) {
+				approval.StartPrompt(os.Stdin, os.Stdout)
+			}
 
 			// Start workers
-			worker.Run(
+			worker.Start(
 				cfg,
-				limiter,
 				limiteds,
 			)
 
-			return nil
+			// Discovered deals join the watchlist while running
+			discovery.Start(cfg.Discovery)
+
+			// Targets can be changed from the control API and the web dashboard while running
+			if control_error := control.Start(cfg.Control); control_error != nil {
+				log.Error("Could not start the control API", "Error", control_error)
+			}
+
+			if ctx.Bool("tui") {
+				return tui.Run()
+			}
+
+			for {
+				time.Sleep(time.Hour)
+			}
 		},
 	}
 
@@ -107,3 +261,12 @@ if (bot_info.Id <= 0) {
 		log.Fatal(err)
 	}
 }
+
+// setupLogging applies the log section of the config, verbose is kept as a shorthand for debug.
+func setupLogging(cfg *config.ConfigStruct) error {
+	level := cfg.Log.Level
+	if cfg.Verbose && (level == "" || level == "info") {
+		level = "debug"
+	}
+	return logging.Init(cfg.Log.Format, level, cfg.Log.Levels)
+}